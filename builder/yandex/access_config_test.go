@@ -0,0 +1,137 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package yandex
+
+import (
+	"os"
+	"testing"
+)
+
+func TestAccessConfigPrepare(t *testing.T) {
+	cases := []struct {
+		Name   string
+		Config AccessConfig
+		Env    map[string]string
+		Err    bool
+	}{
+		{
+			Name:   "token only",
+			Config: AccessConfig{Token: "oauth-token"},
+			Err:    false,
+		},
+		{
+			Name:   "service account key file only",
+			Config: AccessConfig{ServiceAccountKeyFile: TestServiceAccountKeyFile},
+			Err:    false,
+		},
+		{
+			Name: "credentials.iam_token",
+			Config: AccessConfig{
+				Credentials: &CredentialsConfig{Type: "iam_token", IAMToken: "t0"},
+			},
+			Err: false,
+		},
+		{
+			Name: "credentials.iam_token missing iam_token",
+			Config: AccessConfig{
+				Credentials: &CredentialsConfig{Type: "iam_token"},
+			},
+			Err: true,
+		},
+		{
+			Name: "credentials.instance_service_account",
+			Config: AccessConfig{
+				Credentials: &CredentialsConfig{Type: "instance_service_account"},
+			},
+			Err: false,
+		},
+		{
+			Name: "credentials.unknown type",
+			Config: AccessConfig{
+				Credentials: &CredentialsConfig{Type: "bogus"},
+			},
+			Err: true,
+		},
+		{
+			Name: "credentials mutually exclusive with token",
+			Config: AccessConfig{
+				Token:       "oauth-token",
+				Credentials: &CredentialsConfig{Type: "instance_service_account"},
+			},
+			Err: true,
+		},
+		{
+			Name: "credentials mutually exclusive with service_account_key_file",
+			Config: AccessConfig{
+				ServiceAccountKeyFile: TestServiceAccountKeyFile,
+				Credentials:           &CredentialsConfig{Type: "instance_service_account"},
+			},
+			Err: true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			for k, v := range tc.Env {
+				os.Setenv(k, v)
+				defer os.Unsetenv(k)
+			}
+
+			errs := tc.Config.Prepare()
+
+			if tc.Err && len(errs) == 0 {
+				t.Fatalf("expected error, got none")
+			}
+			if !tc.Err && len(errs) != 0 {
+				t.Fatalf("expected no error, got %v", errs)
+			}
+		})
+	}
+}
+
+func TestAccessConfigPrecedenceOverEnv(t *testing.T) {
+	os.Setenv("YC_TOKEN", "env-token")
+	defer os.Unsetenv("YC_TOKEN")
+
+	c := AccessConfig{Token: "explicit-token"}
+	if errs := c.Prepare(); len(errs) != 0 {
+		t.Fatalf("unexpected error: %v", errs)
+	}
+	if c.Token != "explicit-token" {
+		t.Fatalf("explicit token should take precedence over YC_TOKEN, got %q", c.Token)
+	}
+}
+
+// TestAccessConfigTokenPrecedesServiceAccountKeyFile locks in the
+// pre-`credentials` precedence: Token wins over ServiceAccountKeyFile when
+// both are set, matching NewDriverYandexCloud's original switch order.
+func TestAccessConfigTokenPrecedesServiceAccountKeyFile(t *testing.T) {
+	c := AccessConfig{
+		Token:                 "oauth-token",
+		ServiceAccountKeyFile: TestServiceAccountKeyFile,
+	}
+	if errs := c.Prepare(); len(errs) != 0 {
+		t.Fatalf("unexpected error: %v", errs)
+	}
+
+	// TestServiceAccountKeyFile doesn't need to exist for this test: if
+	// Resolve() wrongly preferred ServiceAccountKeyFile, reading it would
+	// fail and we'd get an error back instead of OAuth credentials.
+	if _, err := c.Resolve(); err != nil {
+		t.Fatalf("expected Token to take precedence over ServiceAccountKeyFile when both are set, got error: %s", err)
+	}
+}
+
+func TestAccessConfigFallsBackToEnv(t *testing.T) {
+	os.Setenv("YC_SERVICE_ACCOUNT_KEY_FILE", TestServiceAccountKeyFile)
+	defer os.Unsetenv("YC_SERVICE_ACCOUNT_KEY_FILE")
+
+	c := AccessConfig{}
+	if errs := c.Prepare(); len(errs) != 0 {
+		t.Fatalf("unexpected error: %v", errs)
+	}
+	if c.ServiceAccountKeyFile != TestServiceAccountKeyFile {
+		t.Fatalf("expected service_account_key_file to be populated from YC_SERVICE_ACCOUNT_KEY_FILE, got %q", c.ServiceAccountKeyFile)
+	}
+}
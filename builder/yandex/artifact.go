@@ -0,0 +1,44 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package yandex
+
+import "fmt"
+
+// Artifact represents a Yandex Cloud Compute image produced by a build.
+type Artifact struct {
+	image *Image
+}
+
+func (*Artifact) BuilderId() string {
+	return BuilderId
+}
+
+func (a *Artifact) Id() string {
+	return a.image.ID
+}
+
+func (a *Artifact) Files() []string {
+	return nil
+}
+
+func (a *Artifact) String() string {
+	return fmt.Sprintf("A disk image was created: %s (%s)", a.image.Name, a.image.ID)
+}
+
+func (a *Artifact) State(name string) interface{} {
+	switch name {
+	case "ImageID":
+		return a.image.ID
+	case "ImageName":
+		return a.image.Name
+	case "FolderID":
+		return a.image.FolderID
+	default:
+		return nil
+	}
+}
+
+func (a *Artifact) Destroy() error {
+	return nil
+}
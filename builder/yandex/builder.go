@@ -0,0 +1,94 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package yandex
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/packer/common"
+	"github.com/hashicorp/packer/helper/communicator"
+	"github.com/hashicorp/packer/packer"
+	"github.com/mitchellh/multistep"
+)
+
+// BuilderId is the unique ID for this builder, used in the BuilderId field
+// of the artifacts it produces.
+const BuilderId = "packer.yandex"
+
+type Builder struct {
+	config Config
+	runner multistep.Runner
+}
+
+func (b *Builder) Prepare(raws ...interface{}) ([]string, error) {
+	warns, err := b.config.Prepare(raws...)
+	if err != nil {
+		return warns, err
+	}
+	return warns, nil
+}
+
+func (b *Builder) Run(ctx context.Context, ui packer.Ui, hook packer.Hook) (packer.Artifact, error) {
+	driver, err := NewDriverYandexCloud(ui, &b.config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Yandex Cloud driver: %s", err)
+	}
+
+	state := new(multistep.BasicStateBag)
+	state.Put("config", &b.config)
+	state.Put("driver", driver)
+	state.Put("hook", hook)
+	state.Put("ui", ui)
+
+	// StepPrepareCloudInit runs first so StepCreateInstance can read
+	// "cloud_init_metadata" / "cloud_init_seed_iso" off the state bag;
+	// StepCreateInstance, the communicator, and StepProvision build and
+	// provision the instance; StepCreateImage then captures its boot disk
+	// into the resulting image; StepCleanupImages is appended last so image
+	// rotation only runs after a successful build.
+	steps := []multistep.Step{
+		&StepPrepareCloudInit{},
+		&StepCreateInstance{},
+		&communicator.StepConnect{
+			Config:    &b.config.Communicator,
+			Host:      commHost,
+			SSHConfig: b.config.Communicator.SSHConfigFunc(),
+		},
+		&common.StepProvision{},
+		&StepCreateImage{},
+		&StepCleanupImages{
+			KeepImageCount:     b.config.KeepImageCount,
+			DeprecateOldImages: b.config.DeprecateOldImages,
+		},
+	}
+
+	b.runner = commandRunner(steps)
+	b.runner.Run(ctx, state)
+
+	if rawErr, ok := state.GetOk("error"); ok {
+		return nil, rawErr.(error)
+	}
+
+	artifact, ok := state.GetOk("artifact")
+	if !ok {
+		return nil, fmt.Errorf("build was cancelled before an artifact was produced")
+	}
+
+	return artifact.(packer.Artifact), nil
+}
+
+func commandRunner(steps []multistep.Step) multistep.Runner {
+	return &multistep.BasicRunner{Steps: steps}
+}
+
+// commHost returns the address StepCreateInstance stored for the
+// communicator to connect to.
+func commHost(state multistep.StateBag) (string, error) {
+	address, ok := state.Get("instance_ip").(string)
+	if !ok || address == "" {
+		return "", fmt.Errorf("instance address not available")
+	}
+	return address, nil
+}
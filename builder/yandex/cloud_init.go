@@ -0,0 +1,94 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package yandex
+
+import "fmt"
+
+// CloudInitConfig lets users supply cloud-init data for images whose
+// build-time datasource can't be satisfied by the instance metadata
+// service alone. When SeedISO is true, UserData/NetworkConfig/MetaData are
+// packaged as a NoCloud seed ISO; the ISO is not attached to the instance,
+// and StepCreateInstance halts the build with an error when one is present.
+// Otherwise UserData is injected into the instance's `user-data` metadata
+// key, same as the image's own metadata-service cloud-init would expect.
+type CloudInitConfig struct {
+	// UserData is the cloud-init user-data document, usually a
+	// `#cloud-config` YAML document or a `#!`-prefixed script.
+	UserData string `mapstructure:"user_data"`
+	// NetworkConfig is a cloud-init network-config v1/v2 document. Only
+	// meaningful when SeedISO is true; cloud-init's metadata-service
+	// datasource on Yandex Cloud configures networking on its own.
+	NetworkConfig string `mapstructure:"network_config"`
+	// MetaData is the cloud-init meta-data document. If empty and SeedISO
+	// is true, a minimal one is generated with instance-id and
+	// local-hostname both set to "packer".
+	MetaData string `mapstructure:"meta_data"`
+	// SeedISO renders a NoCloud seed ISO instead of writing UserData into
+	// instance metadata. The ISO is not attached as a disk: a build with
+	// SeedISO set halts in StepCreateInstance with an error.
+	SeedISO bool `mapstructure:"seed_iso"`
+}
+
+func (c *CloudInitConfig) empty() bool {
+	return c.UserData == "" && c.NetworkConfig == "" && c.MetaData == "" && !c.SeedISO
+}
+
+// Validate rejects cloud_init configuration that conflicts with the
+// pre-existing metadata / metadata_from_file keys: when cloud_init isn't
+// using a seed ISO, its user-data is injected into the "user-data"
+// metadata key, so that key must not already be claimed by the user.
+func (c *CloudInitConfig) Validate(metadata map[string]string, metadataFromFile map[string]string) error {
+	if c.empty() {
+		return nil
+	}
+
+	if c.UserData == "" && c.SeedISO {
+		return fmt.Errorf("cloud_init.user_data must be set when cloud_init.seed_iso is true")
+	}
+
+	if c.SeedISO {
+		return nil
+	}
+
+	if _, ok := metadata["user-data"]; ok {
+		return fmt.Errorf("cloud_init.user_data conflicts with metadata[\"user-data\"]; set only one")
+	}
+	if _, ok := metadataFromFile["user-data"]; ok {
+		return fmt.Errorf("cloud_init.user_data conflicts with metadata_from_file[\"user-data\"]; set only one")
+	}
+
+	return nil
+}
+
+// MetadataEntries returns the instance metadata entries that should be
+// merged in for the non-seed-ISO case.
+func (c *CloudInitConfig) MetadataEntries() map[string]string {
+	if c.empty() || c.SeedISO || c.UserData == "" {
+		return nil
+	}
+	return map[string]string{"user-data": c.UserData}
+}
+
+// SeedISOBytes renders the NoCloud seed ISO for this configuration. It
+// returns nil, nil when SeedISO is false.
+func (c *CloudInitConfig) SeedISOBytes() ([]byte, error) {
+	if !c.SeedISO {
+		return nil, nil
+	}
+
+	metaData := c.MetaData
+	if metaData == "" {
+		metaData = "instance-id: packer\nlocal-hostname: packer\n"
+	}
+
+	files := []isoFile{
+		{name: "user-data", data: []byte(c.UserData)},
+		{name: "meta-data", data: []byte(metaData)},
+	}
+	if c.NetworkConfig != "" {
+		files = append(files, isoFile{name: "network-config", data: []byte(c.NetworkConfig)})
+	}
+
+	return writeISO9660("cidata", files)
+}
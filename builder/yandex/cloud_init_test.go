@@ -0,0 +1,121 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package yandex
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestCloudInitConfigValidate(t *testing.T) {
+	cases := []struct {
+		Name             string
+		CloudInit        CloudInitConfig
+		Metadata         map[string]string
+		MetadataFromFile map[string]string
+		Err              bool
+	}{
+		{
+			Name:      "empty cloud_init is ok",
+			CloudInit: CloudInitConfig{},
+			Err:       false,
+		},
+		{
+			Name:      "user_data without metadata conflict",
+			CloudInit: CloudInitConfig{UserData: "#cloud-config\n"},
+			Err:       false,
+		},
+		{
+			Name:      "user_data conflicts with metadata user-data key",
+			CloudInit: CloudInitConfig{UserData: "#cloud-config\n"},
+			Metadata:  map[string]string{"user-data": "something"},
+			Err:       true,
+		},
+		{
+			Name:             "user_data conflicts with metadata_from_file user-data key",
+			CloudInit:        CloudInitConfig{UserData: "#cloud-config\n"},
+			MetadataFromFile: map[string]string{"user-data": "/tmp/file"},
+			Err:              true,
+		},
+		{
+			Name:      "seed_iso bypasses metadata conflict check",
+			CloudInit: CloudInitConfig{UserData: "#cloud-config\n", SeedISO: true},
+			Metadata:  map[string]string{"user-data": "something"},
+			Err:       false,
+		},
+		{
+			Name:      "seed_iso requires user_data",
+			CloudInit: CloudInitConfig{SeedISO: true},
+			Err:       true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			err := tc.CloudInit.Validate(tc.Metadata, tc.MetadataFromFile)
+			if tc.Err && err == nil {
+				t.Fatalf("expected error, got none")
+			}
+			if !tc.Err && err != nil {
+				t.Fatalf("expected no error, got %s", err)
+			}
+		})
+	}
+}
+
+func TestCloudInitConfigMetadataEntries(t *testing.T) {
+	c := CloudInitConfig{UserData: "#cloud-config\n"}
+	entries := c.MetadataEntries()
+	if entries["user-data"] != "#cloud-config\n" {
+		t.Fatalf("expected user-data metadata entry, got %#v", entries)
+	}
+
+	c = CloudInitConfig{UserData: "#cloud-config\n", SeedISO: true}
+	if entries := c.MetadataEntries(); entries != nil {
+		t.Fatalf("expected no metadata entries when seed_iso is set, got %#v", entries)
+	}
+}
+
+func TestCloudInitConfigSeedISOBytes(t *testing.T) {
+	c := CloudInitConfig{
+		UserData:      "#cloud-config\npackage_update: true\n",
+		NetworkConfig: "version: 2\n",
+		SeedISO:       true,
+	}
+
+	data, err := c.SeedISOBytes()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if len(data)%isoSectorSize != 0 {
+		t.Fatalf("iso size %d is not a multiple of the sector size", len(data))
+	}
+
+	pvd := data[16*isoSectorSize : 17*isoSectorSize]
+	if !bytes.Equal(pvd[1:6], []byte("CD001")) {
+		t.Fatalf("expected primary volume descriptor signature CD001, got %q", pvd[1:6])
+	}
+	if !bytes.Contains(pvd[40:72], []byte("cidata")) {
+		t.Fatalf("expected volume label 'cidata' in PVD, got %q", pvd[40:72])
+	}
+
+	if !bytes.Contains(data, []byte("package_update: true")) {
+		t.Fatalf("expected user-data contents to be present in the ISO image")
+	}
+	if !bytes.Contains(data, []byte("version: 2")) {
+		t.Fatalf("expected network-config contents to be present in the ISO image")
+	}
+}
+
+func TestCloudInitConfigSeedISOBytesNotRequested(t *testing.T) {
+	c := CloudInitConfig{UserData: "#cloud-config\n"}
+	data, err := c.SeedISOBytes()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if data != nil {
+		t.Fatalf("expected nil ISO bytes when seed_iso is false")
+	}
+}
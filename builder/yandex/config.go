@@ -0,0 +1,215 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package yandex
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+
+	"github.com/hashicorp/packer/common"
+	"github.com/hashicorp/packer/helper/communicator"
+	"github.com/hashicorp/packer/helper/config"
+	"github.com/hashicorp/packer/packer"
+	"github.com/hashicorp/packer/template/interpolate"
+)
+
+const defaultDiskSizeGb = 10
+const defaultTargetImageFolderID = "hashicorp"
+const defaultZone = "ru-central1-a"
+const defaultGpuPlatformID = "gpu-standard-v1"
+const defaultInstanceCores = 2
+const defaultInstanceMemGb = 2
+
+var imageFamilyRe = regexp.MustCompile(`^[a-z]([-a-z0-9]*[a-z0-9])?$`)
+
+// Config is the configuration structure for the yandex builder. It is
+// decoded from the user's template and then used to drive both the
+// Yandex Cloud SDK client (via AccessConfig) and the build's step list.
+type Config struct {
+	common.PackerConfig `mapstructure:",squash"`
+	AccessConfig        `mapstructure:",squash"`
+	Communicator        communicator.Config `mapstructure:",squash"`
+
+	// FolderID is the Yandex Cloud folder to build in.
+	FolderID string `mapstructure:"folder_id" required:"true"`
+	// Zone is the availability zone to build in.
+	Zone string `mapstructure:"zone"`
+	// SubnetID is the subnet the build instance's network interface
+	// attaches to.
+	SubnetID string `mapstructure:"subnet_id" required:"true"`
+	// ServiceAccountID, if set, is attached to the build instance so it
+	// can call the Yandex Cloud API (e.g. from cloud-init) as that
+	// service account.
+	ServiceAccountID string `mapstructure:"service_account_id"`
+	// InstanceCores is the number of vCPUs given to the build instance.
+	// Defaults to 2.
+	InstanceCores int64 `mapstructure:"instance_cores"`
+	// InstanceMemGb is the amount of memory, in GB, given to the build
+	// instance. Defaults to 2.
+	InstanceMemGb int64 `mapstructure:"instance_mem_gb"`
+
+	// SourceImageID is the exact ID of the image to use as a build source.
+	SourceImageID string `mapstructure:"source_image_id"`
+	// SourceImageFamily resolves to the latest image in the given family.
+	SourceImageFamily string `mapstructure:"source_image_family"`
+	// SourceImage accepts a self-link, an exact image name, or
+	// `family/<name>`; see Driver.ResolveImage.
+	SourceImage string `mapstructure:"source_image"`
+	// SourceImageLabels is a label selector, e.g. {"os": "ubuntu"}.
+	SourceImageLabels map[string]string `mapstructure:"source_image_labels"`
+
+	// ImageName is the name given to the resulting image. Defaults to
+	// "packer-{{timestamp}}".
+	ImageName string `mapstructure:"image_name"`
+	// ImageFamily groups the resulting image with others of the same
+	// family, so source_image_family/source_image can find it later.
+	ImageFamily string `mapstructure:"image_family"`
+	// ImageProductIds is a list of license IDs to attach to the image.
+	ImageProductIds []string `mapstructure:"image_product_ids"`
+	// ImageMinDiskSizeGb is the minimum disk size, in GB, an instance
+	// created from the image must have. Must be >= DiskSizeGb.
+	ImageMinDiskSizeGb int `mapstructure:"image_min_disk_size_gb"`
+
+	// DiskSizeGb is the size, in GB, of the build instance's boot disk.
+	DiskSizeGb int `mapstructure:"disk_size_gb"`
+	// TargetImageFolderID is the folder the resulting image is saved to.
+	TargetImageFolderID string `mapstructure:"target_image_folder_id"`
+	// PlatformID selects the compute platform (instance generation). Set
+	// automatically to a GPU platform if InstanceGpus > 0.
+	PlatformID string `mapstructure:"platform_id"`
+	// InstanceGpus is the number of GPUs to attach to the build instance.
+	InstanceGpus int `mapstructure:"instance_gpus"`
+
+	// Metadata is attached to the build instance verbatim.
+	Metadata map[string]string `mapstructure:"metadata"`
+	// MetadataFromFile maps metadata keys to local files whose contents
+	// become the metadata value.
+	MetadataFromFile map[string]string `mapstructure:"metadata_from_file"`
+
+	// CloudInit renders cloud-init user-data for the build instance,
+	// either via instance metadata or a NoCloud seed ISO.
+	CloudInit CloudInitConfig `mapstructure:"cloud_init"`
+
+	// KeepImageCount, when > 0, enables image rotation: after a
+	// successful build, only the most recent KeepImageCount images in
+	// ImageFamily are kept.
+	KeepImageCount int `mapstructure:"keep_image_count"`
+	// DeprecateOldImages marks images evicted by KeepImageCount as
+	// deprecated instead of deleting them.
+	DeprecateOldImages bool `mapstructure:"deprecate_old_images"`
+
+	ctx interpolate.Context
+}
+
+func (c *Config) Prepare(raws ...interface{}) ([]string, error) {
+	err := config.Decode(c, &config.DecodeOpts{
+		Interpolate:        true,
+		InterpolateContext: &c.ctx,
+	}, raws...)
+	if err != nil {
+		return nil, err
+	}
+
+	var errs *packer.MultiError
+
+	if es := c.AccessConfig.Prepare(); len(es) > 0 {
+		for _, e := range es {
+			errs = packer.MultiErrorAppend(errs, e)
+		}
+	}
+
+	if es := c.Communicator.Prepare(&c.ctx); len(es) > 0 {
+		errs = packer.MultiErrorAppend(errs, es...)
+	}
+
+	if c.FolderID == "" {
+		errs = packer.MultiErrorAppend(errs, fmt.Errorf("folder_id is required"))
+	}
+	if c.SubnetID == "" {
+		errs = packer.MultiErrorAppend(errs, fmt.Errorf("subnet_id is required"))
+	}
+
+	sourceSpec := ImageSourceSpec{
+		SourceImageID:     c.SourceImageID,
+		SourceImageFamily: c.SourceImageFamily,
+		SourceImage:       c.SourceImage,
+		SourceImageLabels: c.SourceImageLabels,
+	}
+	if err := sourceSpec.Validate(); err != nil {
+		errs = packer.MultiErrorAppend(errs, err)
+	}
+
+	if c.ImageFamily != "" && !imageFamilyRe.MatchString(c.ImageFamily) {
+		errs = packer.MultiErrorAppend(errs, fmt.Errorf(
+			"image_family must match %s, got %q", imageFamilyRe.String(), c.ImageFamily))
+	}
+
+	for key, path := range c.MetadataFromFile {
+		if err := validateMetadataFile(key, path); err != nil {
+			errs = packer.MultiErrorAppend(errs, err)
+		}
+	}
+
+	if err := c.CloudInit.Validate(c.Metadata, c.MetadataFromFile); err != nil {
+		errs = packer.MultiErrorAppend(errs, err)
+	}
+
+	if c.DiskSizeGb == 0 {
+		c.DiskSizeGb = defaultDiskSizeGb
+	}
+	if c.ImageMinDiskSizeGb != 0 && c.ImageMinDiskSizeGb < c.DiskSizeGb {
+		errs = packer.MultiErrorAppend(errs, fmt.Errorf(
+			"image_min_disk_size_gb (%d) must be >= disk_size_gb (%d)", c.ImageMinDiskSizeGb, c.DiskSizeGb))
+	}
+
+	if c.TargetImageFolderID == "" {
+		c.TargetImageFolderID = defaultTargetImageFolderID
+	}
+	if c.Zone == "" {
+		c.Zone = defaultZone
+	}
+	if c.InstanceGpus > 0 && c.PlatformID == "" {
+		c.PlatformID = defaultGpuPlatformID
+	}
+	if c.InstanceCores == 0 {
+		c.InstanceCores = defaultInstanceCores
+	}
+	if c.InstanceMemGb == 0 {
+		c.InstanceMemGb = defaultInstanceMemGb
+	}
+
+	if c.KeepImageCount < 0 {
+		errs = packer.MultiErrorAppend(errs, fmt.Errorf("keep_image_count must not be negative"))
+	}
+	if c.DeprecateOldImages && c.KeepImageCount <= 0 {
+		errs = packer.MultiErrorAppend(errs, fmt.Errorf("deprecate_old_images requires keep_image_count to be set"))
+	}
+	if c.KeepImageCount > 0 && c.ImageFamily == "" {
+		errs = packer.MultiErrorAppend(errs, fmt.Errorf("keep_image_count requires image_family to be set, otherwise StepCleanupImages has no family to filter on"))
+	}
+
+	if c.ImageName == "" {
+		c.ImageName = "packer-{{timestamp}}"
+	}
+	imageName, err := interpolate.Render(c.ImageName, &c.ctx)
+	if err != nil {
+		errs = packer.MultiErrorAppend(errs, fmt.Errorf("error interpolating image_name: %s", err))
+	} else {
+		c.ImageName = imageName
+	}
+
+	if errs != nil && len(errs.Errors) > 0 {
+		return nil, errs
+	}
+
+	return nil, nil
+}
+
+func validateMetadataFile(key, path string) error {
+	if _, err := os.Stat(path); err != nil {
+		return fmt.Errorf("cannot access file '%s' with content for value of metadata key '%s': %s", path, key, err)
+	}
+	return nil
+}
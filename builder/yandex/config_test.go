@@ -92,6 +92,17 @@ func TestConfigPrepare(t *testing.T) {
 			false,
 		},
 
+		{
+			"subnet_id",
+			nil,
+			true,
+		},
+		{
+			"subnet_id",
+			"subnet-other",
+			false,
+		},
+
 		{
 			"ssh_timeout",
 			"SO BAD",
@@ -225,6 +236,129 @@ func TestConfigImageMinDiskSize(t *testing.T) {
 	}
 }
 
+func TestConfigKeepImageCountRequiresImageFamily(t *testing.T) {
+	cases := []struct {
+		Name   string
+		Config map[string]interface{}
+		Err    bool
+	}{
+		{
+			Name: "keep_image_count without image_family is an error",
+			Config: map[string]interface{}{
+				"keep_image_count": 3,
+				"image_family":     "",
+			},
+			Err: true,
+		},
+		{
+			Name: "keep_image_count with image_family is ok",
+			Config: map[string]interface{}{
+				"keep_image_count": 3,
+				"image_family":     "bar",
+			},
+			Err: false,
+		},
+		{
+			Name: "keep_image_count unset does not require image_family",
+			Config: map[string]interface{}{
+				"image_family": "",
+			},
+			Err: false,
+		},
+	}
+
+	for _, tc := range cases {
+		raw := testConfig(t)
+
+		for k, v := range tc.Config {
+			raw[k] = v
+		}
+
+		var c Config
+		warns, errs := c.Prepare(raw)
+
+		if tc.Err {
+			testConfigErr(t, warns, errs, tc.Name)
+		} else {
+			testConfigOk(t, warns, errs)
+		}
+	}
+}
+
+func TestConfigSourceImageSelection(t *testing.T) {
+	cases := []struct {
+		Name   string
+		Config map[string]interface{}
+		Err    bool
+	}{
+		{
+			Name:   "source_image_id alone (default testConfig)",
+			Config: map[string]interface{}{},
+			Err:    false,
+		},
+		{
+			Name: "source_image conflicts with source_image_id",
+			Config: map[string]interface{}{
+				"source_image": "family/ubuntu-2204-lts",
+			},
+			Err: true,
+		},
+		{
+			Name: "source_image alone",
+			Config: map[string]interface{}{
+				"source_image_id": "",
+				"source_image":    "family/ubuntu-2204-lts",
+			},
+			Err: false,
+		},
+		{
+			Name: "source_image_labels alone",
+			Config: map[string]interface{}{
+				"source_image_id": "",
+				"source_image_labels": map[string]string{
+					"os": "ubuntu",
+				},
+			},
+			Err: false,
+		},
+		{
+			Name: "source_image conflicts with source_image_labels",
+			Config: map[string]interface{}{
+				"source_image_id": "",
+				"source_image":    "family/ubuntu-2204-lts",
+				"source_image_labels": map[string]string{
+					"os": "ubuntu",
+				},
+			},
+			Err: true,
+		},
+		{
+			Name: "no source image selector at all",
+			Config: map[string]interface{}{
+				"source_image_id": "",
+			},
+			Err: true,
+		},
+	}
+
+	for _, tc := range cases {
+		raw := testConfig(t)
+
+		for k, v := range tc.Config {
+			raw[k] = v
+		}
+
+		var c Config
+		warns, errs := c.Prepare(raw)
+
+		if tc.Err {
+			testConfigErr(t, warns, errs, tc.Name)
+		} else {
+			testConfigOk(t, warns, errs)
+		}
+	}
+}
+
 func TestConfigDefaults(t *testing.T) {
 	cases := []struct {
 		Read  func(c *Config) interface{}
@@ -311,7 +445,8 @@ func testConfig(t *testing.T) (config map[string]interface{}) {
 		"image_product_ids": []string{
 			"test-license",
 		},
-		"zone": "ru-central1-a",
+		"zone":      "ru-central1-a",
+		"subnet_id": "subnet-test",
 	}
 
 	return config
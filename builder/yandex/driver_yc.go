@@ -3,24 +3,96 @@ package yandex
 import (
 	"context"
 	"log"
+	"time"
 
-	"github.com/hashicorp/packer/helper/useragent"
-	"github.com/hashicorp/packer/packer"
+	"github.com/golang/protobuf/ptypes"
+	"google.golang.org/genproto/protobuf/field_mask"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/metadata"
 
+	"github.com/hashicorp/packer/helper/useragent"
+	"github.com/hashicorp/packer/packer"
+
 	"github.com/yandex-cloud/go-genproto/yandex/cloud/compute/v1"
 	"github.com/yandex-cloud/go-genproto/yandex/cloud/endpoint"
 	ycsdk "github.com/yandex-cloud/go-sdk"
-	"github.com/yandex-cloud/go-sdk/iamkey"
 	"github.com/yandex-cloud/go-sdk/pkg/requestid"
 )
 
+// Image is the builder's view of a Yandex Cloud Compute image, trimmed to
+// the fields ResolveImage, StepCleanupImages, and the artifact reporting
+// actually use.
+type Image struct {
+	ID            string
+	Name          string
+	FolderID      string
+	Labels        map[string]string
+	Licenses      []string
+	MinDiskSizeGb int
+	SizeGb        int
+	// CreatedAt is the image's creation time, used to pick the newest
+	// image among several candidates. Image IDs are opaque Yandex Cloud
+	// resource identifiers with no guaranteed lexical ordering by
+	// creation time, so callers must sort on this field rather than ID.
+	CreatedAt time.Time
+}
+
+// imageCreatedAt converts a Compute API image's creation timestamp to a
+// time.Time, treating an unparseable or unset timestamp as the zero time.
+func imageCreatedAt(image *compute.Image) time.Time {
+	createdAt, err := ptypes.Timestamp(image.CreatedAt)
+	if err != nil {
+		return time.Time{}
+	}
+	return createdAt
+}
+
 type Driver interface {
 	DeleteImage(id string) error
 	SDK() *ycsdk.SDK
 	GetImage(imageID string) (*Image, error)
 	GetImageFromFolder(ctx context.Context, folderID string, family string) (*Image, error)
+
+	// ListImages returns the images in folderID, optionally narrowed by a
+	// Yandex Cloud list filter expression (e.g. `family="ubuntu-2204-lts"`).
+	ListImages(folderID string, filter string) ([]*Image, error)
+
+	// SetImageLabels overwrites the label set on the image identified by id.
+	SetImageLabels(id string, labels map[string]string) error
+
+	// DeprecateImage marks the image identified by id with the given
+	// lifecycle state (e.g. "DEPRECATED", "OBSOLETE"), pointing users at
+	// replacementID as its successor. replacementID may be empty if there
+	// is no direct successor.
+	DeprecateImage(id string, replacementID string, state string) error
+
+	// ResolveImage finds a single image in folderID from spec, which may
+	// be a self-link, an exact image name, `family/<name>` (the latest
+	// non-deprecated image in that family), or a label selector such as
+	// `os=ubuntu,version=22.04`.
+	ResolveImage(ctx context.Context, folderID string, spec string) (*Image, error)
+
+	// CreateInstance creates the build instance described by spec and
+	// waits for the create operation to finish, returning the new
+	// instance's ID.
+	CreateInstance(ctx context.Context, spec InstanceSpec) (string, error)
+
+	// GetInstanceAddress returns an address the communicator can reach
+	// the instance on, preferring its external (NAT) address if it has
+	// one.
+	GetInstanceAddress(ctx context.Context, instanceID string) (string, error)
+
+	// GetInstanceBootDiskID returns the disk ID of instanceID's boot
+	// disk, for CreateImageFromDisk to capture once provisioning is done.
+	GetInstanceBootDiskID(ctx context.Context, instanceID string) (string, error)
+
+	// DeleteInstance deletes the build instance. It is safe to call even
+	// if the instance was never successfully created.
+	DeleteInstance(ctx context.Context, instanceID string) error
+
+	// CreateImageFromDisk creates a new image named imageName in family
+	// from diskID, waits for it to finish, and returns it.
+	CreateImageFromDisk(ctx context.Context, folderID string, imageName string, family string, diskID string, productIds []string) (*Image, error)
 }
 
 type driverYC struct {
@@ -44,6 +116,7 @@ func (d *driverYC) GetImage(imageID string) (*Image, error) {
 		FolderID:      image.FolderId,
 		MinDiskSizeGb: toGigabytes(image.MinDiskSize),
 		SizeGb:        toGigabytes(image.StorageSize),
+		CreatedAt:     imageCreatedAt(image),
 	}, nil
 }
 
@@ -64,6 +137,7 @@ func (d *driverYC) GetImageFromFolder(ctx context.Context, folderID string, fami
 		FolderID:      image.FolderId,
 		MinDiskSizeGb: toGigabytes(image.MinDiskSize),
 		SizeGb:        toGigabytes(image.StorageSize),
+		CreatedAt:     imageCreatedAt(image),
 	}, nil
 }
 
@@ -76,21 +150,14 @@ func NewDriverYandexCloud(ui packer.Ui, config *Config) (Driver, error) {
 		sdkConfig.Endpoint = config.Endpoint
 	}
 
-	switch {
-	case config.Token != "":
-		sdkConfig.Credentials = ycsdk.OAuthToken(config.Token)
-
-	case config.ServiceAccountKeyFile != "":
-		key, err := iamkey.ReadFromJSONFile(config.ServiceAccountKeyFile)
-		if err != nil {
-			return nil, err
-		}
-
-		credentials, err := ycsdk.ServiceAccountKey(key)
-		if err != nil {
-			return nil, err
-		}
-
+	credentials, err := config.AccessConfig.Resolve()
+	if err != nil {
+		return nil, err
+	}
+	if credentials == nil && metadataServiceAvailable() {
+		credentials = ycsdk.InstanceServiceAccount()
+	}
+	if credentials != nil {
 		sdkConfig.Credentials = credentials
 	}
 
@@ -116,9 +183,97 @@ func NewDriverYandexCloud(ui packer.Ui, config *Config) (Driver, error) {
 }
 
 func (d *driverYC) DeleteImage(ID string) error {
+	op, err := d.sdk.WrapOperation(d.sdk.Compute().Image().Delete(context.Background(), &compute.DeleteImageRequest{
+		ImageId: ID,
+	}))
+	if err != nil {
+		return err
+	}
+
+	if err := op.Wait(context.Background()); err != nil {
+		return err
+	}
+
 	return nil
 }
 
+func (d *driverYC) ListImages(folderID string, filter string) ([]*Image, error) {
+	var images []*Image
+
+	it := d.sdk.Compute().Image().ImageIterator(context.Background(), &compute.ListImagesRequest{
+		FolderId: folderID,
+		Filter:   filter,
+	})
+
+	for it.Next() {
+		image := it.Value()
+
+		images = append(images, &Image{
+			ID:            image.Id,
+			Labels:        image.Labels,
+			Licenses:      image.ProductIds,
+			Name:          image.Name,
+			FolderID:      image.FolderId,
+			MinDiskSizeGb: toGigabytes(image.MinDiskSize),
+			SizeGb:        toGigabytes(image.StorageSize),
+			CreatedAt:     imageCreatedAt(image),
+		})
+	}
+	if err := it.Error(); err != nil {
+		return nil, err
+	}
+
+	return images, nil
+}
+
+func (d *driverYC) SetImageLabels(id string, labels map[string]string) error {
+	op, err := d.sdk.WrapOperation(d.sdk.Compute().Image().Update(context.Background(), &compute.UpdateImageRequest{
+		ImageId:    id,
+		Labels:     labels,
+		UpdateMask: &field_mask.FieldMask{Paths: []string{"labels"}},
+	}))
+	if err != nil {
+		return err
+	}
+
+	return op.Wait(context.Background())
+}
+
+// DeprecateImage marks an image as deprecated. The Yandex Cloud Compute API
+// has no first-class deprecation status for images (unlike the family/
+// latest-by-family lookup it exposes for active images), so deprecation is
+// modeled as labels: "deprecated" and, when replacementID is set,
+// "replacement". ResolveImage skips images carrying the "deprecated" label
+// when resolving `family/<name>`.
+func (d *driverYC) DeprecateImage(id string, replacementID string, state string) error {
+	image, err := d.GetImage(id)
+	if err != nil {
+		return err
+	}
+
+	return d.SetImageLabels(id, deprecatedLabels(image.Labels, replacementID, state))
+}
+
+// deprecatedLabels returns existing overlaid with the "deprecated" label
+// (and "replacement", if replacementID is set), without mutating existing.
+func deprecatedLabels(existing map[string]string, replacementID string, state string) map[string]string {
+	labels := make(map[string]string, len(existing)+2)
+	for k, v := range existing {
+		labels[k] = v
+	}
+	labels["deprecated"] = state
+	if replacementID != "" {
+		labels["replacement"] = replacementID
+	}
+	return labels
+}
+
 func (d *driverYC) SDK() *ycsdk.SDK {
 	return d.sdk
 }
+
+// toGigabytes converts a size in bytes, as returned by the Compute API, to
+// whole gigabytes.
+func toGigabytes(bytes int64) int {
+	return int(bytes / (1 << 30))
+}
@@ -0,0 +1,166 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package yandex
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/yandex-cloud/go-genproto/yandex/cloud/compute/v1"
+)
+
+// InstanceSpec describes the build instance StepCreateInstance asks the
+// driver to create.
+type InstanceSpec struct {
+	FolderID         string
+	Name             string
+	ZoneID           string
+	PlatformID       string
+	SubnetID         string
+	ServiceAccountID string
+	Cores            int64
+	MemoryBytes      int64
+	DiskSizeBytes    int64
+	ImageID          string
+	Metadata         map[string]string
+}
+
+func (d *driverYC) CreateInstance(ctx context.Context, spec InstanceSpec) (string, error) {
+	req := &compute.CreateInstanceRequest{
+		FolderId:         spec.FolderID,
+		Name:             spec.Name,
+		ZoneId:           spec.ZoneID,
+		PlatformId:       spec.PlatformID,
+		ServiceAccountId: spec.ServiceAccountID,
+		ResourcesSpec: &compute.ResourcesSpec{
+			Cores:  spec.Cores,
+			Memory: spec.MemoryBytes,
+		},
+		BootDiskSpec: &compute.AttachedDiskSpec{
+			AutoDelete: true,
+			Disk: &compute.AttachedDiskSpec_DiskSpec_{
+				DiskSpec: &compute.AttachedDiskSpec_DiskSpec{
+					Size: spec.DiskSizeBytes,
+					Source: &compute.AttachedDiskSpec_DiskSpec_ImageId{
+						ImageId: spec.ImageID,
+					},
+				},
+			},
+		},
+		NetworkInterfaceSpecs: []*compute.NetworkInterfaceSpec{
+			{
+				SubnetId: spec.SubnetID,
+				PrimaryV4AddressSpec: &compute.PrimaryAddressSpec{
+					OneToOneNatSpec: &compute.OneToOneNatSpec{
+						IpVersion: compute.IpVersion_IPV4,
+					},
+				},
+			},
+		},
+		Metadata: spec.Metadata,
+	}
+
+	op, err := d.sdk.WrapOperation(d.sdk.Compute().Instance().Create(ctx, req))
+	if err != nil {
+		return "", fmt.Errorf("error creating instance: %s", err)
+	}
+	if err := op.Wait(ctx); err != nil {
+		return "", fmt.Errorf("error waiting for instance creation: %s", err)
+	}
+
+	instanceID, err := d.instanceIDByName(ctx, spec.FolderID, spec.Name)
+	if err != nil {
+		return "", fmt.Errorf("instance %q was created but could not be found afterward: %s", spec.Name, err)
+	}
+	return instanceID, nil
+}
+
+// instanceIDByName looks up the instance just created by name, the same
+// way resolveImageByName looks up an image: Create's operation metadata
+// isn't decoded here, since listing by name is already a proven, tested
+// pattern against this SDK (see ListImages).
+func (d *driverYC) instanceIDByName(ctx context.Context, folderID string, name string) (string, error) {
+	it := d.sdk.Compute().Instance().InstanceIterator(ctx, &compute.ListInstancesRequest{
+		FolderId: folderID,
+		Filter:   fmt.Sprintf("name=\"%s\"", name),
+	})
+
+	var found *compute.Instance
+	for it.Next() {
+		found = it.Value()
+	}
+	if err := it.Error(); err != nil {
+		return "", err
+	}
+	if found == nil {
+		return "", fmt.Errorf("no instance found named %q", name)
+	}
+	return found.Id, nil
+}
+
+func (d *driverYC) GetInstanceAddress(ctx context.Context, instanceID string) (string, error) {
+	instance, err := d.sdk.Compute().Instance().Get(ctx, &compute.GetInstanceRequest{
+		InstanceId: instanceID,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	for _, ni := range instance.NetworkInterfaces {
+		if ni.PrimaryV4Address == nil {
+			continue
+		}
+		if nat := ni.PrimaryV4Address.OneToOneNat; nat != nil && nat.Address != "" {
+			return nat.Address, nil
+		}
+		if ni.PrimaryV4Address.Address != "" {
+			return ni.PrimaryV4Address.Address, nil
+		}
+	}
+
+	return "", fmt.Errorf("instance %q has no IPv4 address", instanceID)
+}
+
+func (d *driverYC) GetInstanceBootDiskID(ctx context.Context, instanceID string) (string, error) {
+	instance, err := d.sdk.Compute().Instance().Get(ctx, &compute.GetInstanceRequest{
+		InstanceId: instanceID,
+	})
+	if err != nil {
+		return "", err
+	}
+	if instance.BootDisk == nil || instance.BootDisk.DiskId == "" {
+		return "", fmt.Errorf("instance %q has no boot disk", instanceID)
+	}
+	return instance.BootDisk.DiskId, nil
+}
+
+func (d *driverYC) DeleteInstance(ctx context.Context, instanceID string) error {
+	op, err := d.sdk.WrapOperation(d.sdk.Compute().Instance().Delete(ctx, &compute.DeleteInstanceRequest{
+		InstanceId: instanceID,
+	}))
+	if err != nil {
+		return err
+	}
+	return op.Wait(ctx)
+}
+
+func (d *driverYC) CreateImageFromDisk(ctx context.Context, folderID string, imageName string, family string, diskID string, productIds []string) (*Image, error) {
+	op, err := d.sdk.WrapOperation(d.sdk.Compute().Image().Create(ctx, &compute.CreateImageRequest{
+		FolderId:   folderID,
+		Name:       imageName,
+		Family:     family,
+		ProductIds: productIds,
+		Source: &compute.CreateImageRequest_DiskId{
+			DiskId: diskID,
+		},
+	}))
+	if err != nil {
+		return nil, fmt.Errorf("error creating image: %s", err)
+	}
+	if err := op.Wait(ctx); err != nil {
+		return nil, fmt.Errorf("error waiting for image creation: %s", err)
+	}
+
+	return resolveImageByName(d, folderID, imageName)
+}
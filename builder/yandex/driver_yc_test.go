@@ -0,0 +1,58 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package yandex
+
+import "testing"
+
+func TestDeprecatedLabels(t *testing.T) {
+	cases := []struct {
+		Name          string
+		Existing      map[string]string
+		ReplacementID string
+		State         string
+		Want          map[string]string
+	}{
+		{
+			Name:     "no existing labels, no replacement",
+			Existing: nil,
+			State:    "DEPRECATED",
+			Want:     map[string]string{"deprecated": "DEPRECATED"},
+		},
+		{
+			Name:          "no existing labels, with replacement",
+			Existing:      nil,
+			ReplacementID: "img-new",
+			State:         "DEPRECATED",
+			Want:          map[string]string{"deprecated": "DEPRECATED", "replacement": "img-new"},
+		},
+		{
+			Name:     "existing labels are preserved",
+			Existing: map[string]string{"os": "ubuntu"},
+			State:    "OBSOLETE",
+			Want:     map[string]string{"os": "ubuntu", "deprecated": "OBSOLETE"},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			got := deprecatedLabels(tc.Existing, tc.ReplacementID, tc.State)
+			if len(got) != len(tc.Want) {
+				t.Fatalf("expected %#v, got %#v", tc.Want, got)
+			}
+			for k, v := range tc.Want {
+				if got[k] != v {
+					t.Fatalf("expected %#v, got %#v", tc.Want, got)
+				}
+			}
+		})
+	}
+
+	t.Run("does not mutate existing", func(t *testing.T) {
+		existing := map[string]string{"os": "ubuntu"}
+		deprecatedLabels(existing, "", "DEPRECATED")
+		if len(existing) != 1 {
+			t.Fatalf("expected existing to be untouched, got %#v", existing)
+		}
+	})
+}
@@ -0,0 +1,67 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package yandex
+
+import "fmt"
+
+// ImageSourceSpec mirrors the source-image-selection fields of Config and
+// validates that they pick a source image in exactly one way.
+// SourceImage accepts a self-link, an exact image name, or `family/<name>`;
+// SourceImageLabels is a label selector such as `{"os": "ubuntu"}`.
+type ImageSourceSpec struct {
+	SourceImageID     string
+	SourceImageFamily string
+	SourceImage       string
+	SourceImageLabels map[string]string
+}
+
+// Validate enforces that a source image is selected, and that source_image
+// and source_image_labels are each used on their own: neither may be
+// combined with the other or with source_image_id / source_image_family.
+// source_image_id and source_image_family may be set together, in which
+// case source_image_id is used.
+func (s *ImageSourceSpec) Validate() error {
+	set := 0
+	if s.SourceImageID != "" || s.SourceImageFamily != "" {
+		set++
+	}
+	if s.SourceImage != "" {
+		set++
+	}
+	if len(s.SourceImageLabels) > 0 {
+		set++
+	}
+
+	if set == 0 {
+		return fmt.Errorf("one of source_image_id, source_image_family, source_image, or source_image_labels must be specified")
+	}
+	if set > 1 {
+		return fmt.Errorf("source_image and source_image_labels are mutually exclusive with each other " +
+			"and with source_image_id / source_image_family; specify exactly one way to select a source image")
+	}
+
+	return nil
+}
+
+// Spec returns the spec string to pass to Driver.ResolveImage for the
+// source_image / source_image_labels fields. It is only meaningful once
+// Validate has confirmed at most one of them is set.
+func (s *ImageSourceSpec) Spec() string {
+	if s.SourceImage != "" {
+		return s.SourceImage
+	}
+
+	if len(s.SourceImageLabels) == 0 {
+		return ""
+	}
+
+	spec := ""
+	for k, v := range s.SourceImageLabels {
+		if spec != "" {
+			spec += ","
+		}
+		spec += fmt.Sprintf("%s=%s", k, v)
+	}
+	return spec
+}
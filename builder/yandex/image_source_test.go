@@ -0,0 +1,79 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package yandex
+
+import "testing"
+
+func TestImageSourceSpecValidate(t *testing.T) {
+	cases := []struct {
+		Name string
+		Spec ImageSourceSpec
+		Err  bool
+	}{
+		{
+			Name: "none set is an error",
+			Spec: ImageSourceSpec{},
+			Err:  true,
+		},
+		{
+			Name: "source_image_id only",
+			Spec: ImageSourceSpec{SourceImageID: "foo"},
+			Err:  false,
+		},
+		{
+			Name: "source_image_family only",
+			Spec: ImageSourceSpec{SourceImageFamily: "bar"},
+			Err:  false,
+		},
+		{
+			Name: "source_image only",
+			Spec: ImageSourceSpec{SourceImage: "family/ubuntu-2204-lts"},
+			Err:  false,
+		},
+		{
+			Name: "source_image_labels only",
+			Spec: ImageSourceSpec{SourceImageLabels: map[string]string{"os": "ubuntu"}},
+			Err:  false,
+		},
+		{
+			Name: "source_image_id and source_image_family may be set together",
+			Spec: ImageSourceSpec{SourceImageID: "foo", SourceImageFamily: "bar"},
+			Err:  false,
+		},
+		{
+			Name: "source_image and source_image_id conflict",
+			Spec: ImageSourceSpec{SourceImage: "family/bar", SourceImageID: "foo"},
+			Err:  true,
+		},
+		{
+			Name: "source_image and source_image_labels conflict",
+			Spec: ImageSourceSpec{SourceImage: "foo", SourceImageLabels: map[string]string{"os": "ubuntu"}},
+			Err:  true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			err := tc.Spec.Validate()
+			if tc.Err && err == nil {
+				t.Fatalf("expected error, got none")
+			}
+			if !tc.Err && err != nil {
+				t.Fatalf("expected no error, got %s", err)
+			}
+		})
+	}
+}
+
+func TestImageSourceSpecSpec(t *testing.T) {
+	s := ImageSourceSpec{SourceImage: "family/ubuntu-2204-lts"}
+	if got := s.Spec(); got != "family/ubuntu-2204-lts" {
+		t.Fatalf("expected %q, got %q", "family/ubuntu-2204-lts", got)
+	}
+
+	s = ImageSourceSpec{SourceImageLabels: map[string]string{"os": "ubuntu"}}
+	if got := s.Spec(); got != "os=ubuntu" {
+		t.Fatalf("expected %q, got %q", "os=ubuntu", got)
+	}
+}
@@ -0,0 +1,272 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package yandex
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const isoSectorSize = 2048
+
+// isoFile is one file written into the root directory of a NoCloud seed
+// ISO: "user-data", "meta-data", and optionally "network-config".
+type isoFile struct {
+	name string
+	data []byte
+}
+
+// writeISO9660 returns a minimal, single-directory ISO9660 image containing
+// files, with the given volume label. It implements just enough of
+// ECMA-119 for cloud-init's NoCloud datasource to mount and read it: a
+// primary volume descriptor, a terminator, one set of path tables, and a
+// flat root directory holding the given files. There are no subdirectories
+// and no Joliet/Rock Ridge extensions: each file name is upper-cased and
+// given a ";1" version suffix (e.g. "USER-DATA;1"), and is not otherwise
+// shortened or checked against 8.3 limits.
+func writeISO9660(volumeLabel string, files []isoFile) ([]byte, error) {
+	if len(volumeLabel) > 32 {
+		return nil, fmt.Errorf("iso9660 volume label %q exceeds 32 characters", volumeLabel)
+	}
+
+	// Lay out extents in sectors, in order: system area (16), PVD (1),
+	// terminator (1), path table L (1), path table M (1), root directory
+	// (1), then one extent per file.
+	const (
+		sysAreaSectors = 16
+		pvdSector      = sysAreaSectors
+		termSector     = pvdSector + 1
+		pathTableLSec  = termSector + 1
+		pathTableMSec  = pathTableLSec + 1
+		rootDirSector  = pathTableMSec + 1
+	)
+
+	fileSectors := make([]uint32, len(files))
+	nextSector := uint32(rootDirSector + 1)
+	for i, f := range files {
+		fileSectors[i] = nextSector
+		nextSector += sectorsFor(len(f.data))
+	}
+	totalSectors := nextSector
+
+	buf := make([]byte, int(totalSectors)*isoSectorSize)
+
+	rootDir := buildRootDirectory(rootDirSector, files, fileSectors)
+	if len(rootDir) > isoSectorSize {
+		return nil, fmt.Errorf("iso9660 root directory does not fit in one sector (%d files)", len(files))
+	}
+	copy(buf[rootDirSector*isoSectorSize:], rootDir)
+
+	pathTable := buildPathTable(rootDirSector, true)
+	copy(buf[pathTableLSec*isoSectorSize:], pathTable)
+	copy(buf[pathTableMSec*isoSectorSize:], buildPathTable(rootDirSector, false))
+
+	for i, f := range files {
+		offset := int(fileSectors[i]) * isoSectorSize
+		copy(buf[offset:], f.data)
+	}
+
+	rootDirRecord := directoryRecord(".", rootDirSector, len(rootDir), true)
+	pvd := buildPrimaryVolumeDescriptor(volumeLabel, totalSectors, len(pathTable), pathTableLSec, pathTableMSec, rootDirRecord)
+	copy(buf[pvdSector*isoSectorSize:], pvd)
+	copy(buf[termSector*isoSectorSize:], buildVolumeDescriptorTerminator())
+
+	return buf, nil
+}
+
+func sectorsFor(n int) uint32 {
+	if n == 0 {
+		return 1
+	}
+	return uint32((n + isoSectorSize - 1) / isoSectorSize)
+}
+
+// int32LSB encodes v as a 4-byte little-endian field (ECMA-119 type 731,
+// used by the Location of Type L Path Table).
+func int32LSB(v uint32) []byte {
+	return []byte{byte(v), byte(v >> 8), byte(v >> 16), byte(v >> 24)}
+}
+
+// int32MSB encodes v as a 4-byte big-endian field (ECMA-119 type 732, used
+// by the Location of Type M Path Table).
+func int32MSB(v uint32) []byte {
+	return []byte{byte(v >> 24), byte(v >> 16), byte(v >> 8), byte(v)}
+}
+
+// int16LSB encodes v as a 2-byte little-endian field.
+func int16LSB(v uint16) []byte {
+	return []byte{byte(v), byte(v >> 8)}
+}
+
+// int16MSB encodes v as a 2-byte big-endian field.
+func int16MSB(v uint16) []byte {
+	return []byte{byte(v >> 8), byte(v)}
+}
+
+// lsbMsb32 encodes v as both-byte-order 8 bytes (ECMA-119 type 733), used
+// for fields such as a directory record's Location of Extent or Data
+// Length, and the PVD's Volume Space Size / Path Table Size.
+func lsbMsb32(v uint32) []byte {
+	return append(int32LSB(v), int32MSB(v)...)
+}
+
+// lsbMsb16 encodes v as both-byte-order 4 bytes (ECMA-119 type 723), used
+// for fields such as the Volume Set Size, Volume Sequence Number, and
+// Logical Block Size.
+func lsbMsb16(v uint16) []byte {
+	return append(int16LSB(v), int16MSB(v)...)
+}
+
+func isoDateTime(t time.Time) []byte {
+	return []byte{
+		byte(t.Year() - 1900), byte(t.Month()), byte(t.Day()),
+		byte(t.Hour()), byte(t.Minute()), byte(t.Second()), 0,
+	}
+}
+
+func padA(s string, n int) string {
+	if len(s) > n {
+		s = s[:n]
+	}
+	return s + strings.Repeat(" ", n-len(s))
+}
+
+// directoryRecord builds one ECMA-119 directory record (section 9.1): a
+// 1-byte length prefix followed by the extent location, data length,
+// timestamp, flags, and file identifier, padded to an even length.
+func directoryRecord(name string, extentSector uint32, length int, isDir bool) []byte {
+	id := name
+	flags := byte(0)
+	if isDir {
+		flags = 0x02
+	}
+
+	idBytes := []byte(id)
+	idLen := len(idBytes)
+	recLen := 33 + idLen
+	if recLen%2 != 0 {
+		recLen++
+	}
+
+	rec := make([]byte, recLen)
+	rec[0] = byte(recLen)
+	rec[1] = 0 // extended attribute record length
+	copy(rec[2:10], lsbMsb32(extentSector))
+	copy(rec[10:18], lsbMsb32(uint32(length)))
+	copy(rec[18:25], isoDateTime(time.Now()))
+	rec[25] = flags
+	rec[26] = 0 // file unit size
+	rec[27] = 0 // interleave gap
+	copy(rec[28:32], lsbMsb16(1))
+	rec[32] = byte(idLen)
+	copy(rec[33:33+idLen], idBytes)
+
+	return rec
+}
+
+func buildRootDirectory(selfSector uint32, files []isoFile, fileSectors []uint32) []byte {
+	var buf bytes.Buffer
+
+	buf.Write(directoryRecord("\x00", selfSector, isoSectorSize, true))
+	buf.Write(directoryRecord("\x01", selfSector, isoSectorSize, true))
+
+	for i, f := range files {
+		buf.Write(directoryRecord(strings.ToUpper(f.name)+";1", fileSectors[i], len(f.data), false))
+	}
+
+	return buf.Bytes()
+}
+
+// buildPathTable builds an ECMA-119 path table (section 9.4) holding a
+// single entry for the root directory. littleEndian selects the Type L
+// (LSB) table; otherwise the Type M (MSB) table is built. Both tables
+// encode the same 10-byte entry, differing only in the byte order of the
+// extent location and parent directory number.
+func buildPathTable(rootDirSector uint32, littleEndian bool) []byte {
+	var buf bytes.Buffer
+
+	buf.WriteByte(1) // length of directory identifier ("\x00" for root)
+	buf.WriteByte(0) // extended attribute record length
+
+	if littleEndian {
+		buf.Write(int32LSB(rootDirSector))
+		buf.Write(int16LSB(1)) // parent directory number: root is its own parent
+	} else {
+		buf.Write(int32MSB(rootDirSector))
+		buf.Write(int16MSB(1))
+	}
+
+	buf.WriteByte(0) // root directory identifier, the spec's empty-name byte
+	buf.WriteByte(0) // padding to keep the entry even-length
+
+	return buf.Bytes()
+}
+
+// buildPrimaryVolumeDescriptor builds the ECMA-119 Primary Volume
+// Descriptor (section 8.4). Field offsets below are relative to the start
+// of the descriptor and match the spec's table 8.4 exactly:
+//
+//	80-87   Volume Space Size              (733, 8 bytes)
+//	120-123 Volume Set Size                (723, 4 bytes)
+//	124-127 Volume Sequence Number         (723, 4 bytes)
+//	128-131 Logical Block Size             (723, 4 bytes)
+//	132-139 Path Table Size                (733, 8 bytes)
+//	140-143 Location of Type L Path Table  (731, 4 bytes)
+//	144-147 Location of Optional Type L Path Table (731, 4 bytes, unused)
+//	148-151 Location of Type M Path Table  (732, 4 bytes)
+//	152-155 Location of Optional Type M Path Table (732, 4 bytes, unused)
+//	156-189 Directory Record for Root Directory    (34 bytes)
+func buildPrimaryVolumeDescriptor(volumeLabel string, totalSectors uint32, pathTableSize int, pathTableLSec, pathTableMSec uint32, rootDirRecord []byte) []byte {
+	pvd := make([]byte, isoSectorSize)
+
+	pvd[0] = 1 // volume descriptor type: primary
+	copy(pvd[1:6], []byte("CD001"))
+	pvd[6] = 1 // version
+
+	copy(pvd[8:40], padA("", 32))           // system identifier
+	copy(pvd[40:72], padA(volumeLabel, 32)) // volume identifier
+
+	copy(pvd[80:88], lsbMsb32(totalSectors)) // volume space size
+
+	copy(pvd[120:124], lsbMsb16(1))                     // volume set size
+	copy(pvd[124:128], lsbMsb16(1))                     // volume sequence number
+	copy(pvd[128:132], lsbMsb16(uint16(isoSectorSize))) // logical block size
+
+	copy(pvd[132:140], lsbMsb32(uint32(pathTableSize))) // path table size
+	copy(pvd[140:144], int32LSB(pathTableLSec))         // location of type L path table
+	// pvd[144:148]: location of optional type L path table, left unset (0)
+	copy(pvd[148:152], int32MSB(pathTableMSec)) // location of type M path table
+	// pvd[152:156]: location of optional type M path table, left unset (0)
+
+	copy(pvd[156:156+len(rootDirRecord)], rootDirRecord) // directory record for root directory
+
+	now := time.Now()
+	copy(pvd[813:830], volumeTimestamp(now)) // creation date
+	copy(pvd[830:847], volumeTimestamp(now)) // modification date
+	copy(pvd[847:864], make([]byte, 17))     // expiration date: unset
+	copy(pvd[864:881], make([]byte, 17))     // effective date: unset
+	pvd[881] = 1                             // file structure version
+
+	return pvd
+}
+
+// volumeTimestamp formats a time.Time as the 17-byte decimal datetime used
+// by the PVD's creation/modification/expiration/effective date fields.
+func volumeTimestamp(t time.Time) []byte {
+	s := t.Format("20060102150405") + "00"
+	out := make([]byte, 17)
+	copy(out, []byte(s))
+	out[16] = 0 // GMT offset, in 15-minute intervals from GMT
+	return out
+}
+
+func buildVolumeDescriptorTerminator() []byte {
+	term := make([]byte, isoSectorSize)
+	term[0] = 255
+	copy(term[1:6], []byte("CD001"))
+	term[6] = 1
+	return term
+}
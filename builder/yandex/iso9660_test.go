@@ -0,0 +1,141 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package yandex
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+// parsedPVD holds the fields of a parsed primary volume descriptor needed
+// to walk the rest of the image.
+type parsedPVD struct {
+	pathTableSize int
+	pathTableLSec uint32
+	pathTableMSec uint32
+	rootExtent    uint32
+	rootLength    int
+}
+
+func parsePVD(t *testing.T, data []byte) parsedPVD {
+	t.Helper()
+
+	pvd := data[16*isoSectorSize : 17*isoSectorSize]
+	if pvd[0] != 1 {
+		t.Fatalf("expected volume descriptor type 1 (primary), got %d", pvd[0])
+	}
+	if !bytes.Equal(pvd[1:6], []byte("CD001")) {
+		t.Fatalf("expected primary volume descriptor signature CD001, got %q", pvd[1:6])
+	}
+
+	pathTableSize := int(uint32(pvd[132]) | uint32(pvd[133])<<8 | uint32(pvd[134])<<16 | uint32(pvd[135])<<24)
+	pathTableLSec := uint32(pvd[140]) | uint32(pvd[141])<<8 | uint32(pvd[142])<<16 | uint32(pvd[143])<<24
+	pathTableMSec := uint32(pvd[148])<<24 | uint32(pvd[149])<<16 | uint32(pvd[150])<<8 | uint32(pvd[151])
+
+	if pathTableSize != 10 {
+		t.Fatalf("expected a 10-byte path table for a root-only tree, got %d", pathTableSize)
+	}
+
+	rootDirRecord := pvd[156:190]
+	rootExtent := uint32(rootDirRecord[2]) | uint32(rootDirRecord[3])<<8 | uint32(rootDirRecord[4])<<16 | uint32(rootDirRecord[5])<<24
+	rootLength := int(uint32(rootDirRecord[10]) | uint32(rootDirRecord[11])<<8 | uint32(rootDirRecord[12])<<16 | uint32(rootDirRecord[13])<<24)
+
+	return parsedPVD{
+		pathTableSize: pathTableSize,
+		pathTableLSec: pathTableLSec,
+		pathTableMSec: pathTableMSec,
+		rootExtent:    rootExtent,
+		rootLength:    rootLength,
+	}
+}
+
+// parseDirectoryRecords walks a buffer of consecutive ECMA-119 directory
+// records and returns each one's identifier, extent location, and length.
+func parseDirectoryRecords(t *testing.T, dir []byte) map[string]struct {
+	extent uint32
+	length int
+} {
+	t.Helper()
+
+	entries := map[string]struct {
+		extent uint32
+		length int
+	}{}
+
+	for i := 0; i < len(dir); {
+		recLen := int(dir[i])
+		if recLen == 0 {
+			break
+		}
+		rec := dir[i : i+recLen]
+
+		extent := uint32(rec[2]) | uint32(rec[3])<<8 | uint32(rec[4])<<16 | uint32(rec[5])<<24
+		length := int(uint32(rec[10]) | uint32(rec[11])<<8 | uint32(rec[12])<<16 | uint32(rec[13])<<24)
+		idLen := int(rec[32])
+		id := string(rec[33 : 33+idLen])
+
+		entries[id] = struct {
+			extent uint32
+			length int
+		}{extent, length}
+
+		i += recLen
+	}
+
+	return entries
+}
+
+// TestWriteISO9660DirectoryStructure builds an ISO with several files and
+// walks the actual path table and root directory records it produced,
+// rather than just checking the PVD signature or grepping for file
+// contents, so that a wrong field offset in buildPrimaryVolumeDescriptor
+// or buildPathTable fails this test instead of silently producing an
+// unmountable image.
+func TestWriteISO9660DirectoryStructure(t *testing.T) {
+	files := []isoFile{
+		{name: "user-data", data: []byte("#cloud-config\npackage_update: true\n")},
+		{name: "meta-data", data: []byte("instance-id: packer\n")},
+		{name: "network-config", data: []byte("version: 2\n")},
+	}
+
+	data, err := writeISO9660("cidata", files)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(data)%isoSectorSize != 0 {
+		t.Fatalf("iso size %d is not a multiple of the sector size", len(data))
+	}
+
+	pvd := parsePVD(t, data)
+
+	typeLPathTable := data[int(pvd.pathTableLSec)*isoSectorSize : int(pvd.pathTableLSec)*isoSectorSize+pvd.pathTableSize]
+	if want := buildPathTable(pvd.rootExtent, true); !bytes.Equal(typeLPathTable, want) {
+		t.Fatalf("type L path table at its recorded location doesn't match the root directory's recorded extent:\ngot  %x\nwant %x", typeLPathTable, want)
+	}
+
+	typeMPathTable := data[int(pvd.pathTableMSec)*isoSectorSize : int(pvd.pathTableMSec)*isoSectorSize+pvd.pathTableSize]
+	if want := buildPathTable(pvd.rootExtent, false); !bytes.Equal(typeMPathTable, want) {
+		t.Fatalf("type M path table at its recorded location doesn't match the root directory's recorded extent:\ngot  %x\nwant %x", typeMPathTable, want)
+	}
+
+	rootDir := data[int(pvd.rootExtent)*isoSectorSize : int(pvd.rootExtent)*isoSectorSize+pvd.rootLength]
+	entries := parseDirectoryRecords(t, rootDir)
+
+	for _, f := range files {
+		id := strings.ToUpper(f.name) + ";1"
+		entry, ok := entries[id]
+		if !ok {
+			t.Fatalf("root directory has no entry %q; entries: %#v", id, entries)
+		}
+		if entry.length != len(f.data) {
+			t.Fatalf("entry %q: expected length %d, got %d", id, len(f.data), entry.length)
+		}
+
+		got := data[int(entry.extent)*isoSectorSize : int(entry.extent)*isoSectorSize+entry.length]
+		if !bytes.Equal(got, f.data) {
+			t.Fatalf("entry %q: file contents at its recorded extent don't match: got %q, want %q", id, got, f.data)
+		}
+	}
+}
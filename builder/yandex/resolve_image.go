@@ -0,0 +1,118 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package yandex
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+const selfLinkPrefix = "https://"
+
+// imageLister is the subset of Driver that resolveImage and its helpers
+// need, so they can be tested against a fake instead of a real SDK client.
+type imageLister interface {
+	GetImage(imageID string) (*Image, error)
+	ListImages(folderID string, filter string) ([]*Image, error)
+}
+
+// ResolveImage implements Driver.ResolveImage. See the Driver interface
+// doc comment for the accepted spec forms.
+func (d *driverYC) ResolveImage(ctx context.Context, folderID string, spec string) (*Image, error) {
+	return resolveImage(d, folderID, spec)
+}
+
+func resolveImage(d imageLister, folderID string, spec string) (*Image, error) {
+	switch {
+	case strings.HasPrefix(spec, selfLinkPrefix):
+		return d.GetImage(selfLinkImageID(spec))
+
+	case strings.HasPrefix(spec, "family/"):
+		return resolveLatestInFamily(d, folderID, strings.TrimPrefix(spec, "family/"))
+
+	case strings.Contains(spec, "="):
+		return resolveImageByLabels(d, folderID, spec)
+
+	default:
+		return resolveImageByName(d, folderID, spec)
+	}
+}
+
+// resolveLatestInFamily returns the most recent image in family that isn't
+// marked deprecated via the "deprecated" label DeprecateImage writes.
+// GetImageFromFolder/GetLatestByFamily is not used here because the
+// Compute API's own "latest by family" has no notion of that label.
+func resolveLatestInFamily(d imageLister, folderID string, family string) (*Image, error) {
+	images, err := d.ListImages(folderID, fmt.Sprintf("family=\"%s\"", family))
+	if err != nil {
+		return nil, err
+	}
+
+	var candidates []*Image
+	for _, image := range images {
+		if _, deprecated := image.Labels["deprecated"]; deprecated {
+			continue
+		}
+		candidates = append(candidates, image)
+	}
+
+	if len(candidates) == 0 {
+		return nil, fmt.Errorf("no non-deprecated image found in family %q", family)
+	}
+
+	// Image IDs are opaque Yandex Cloud resource identifiers with no
+	// guaranteed lexical ordering by creation time, so the newest image
+	// must be picked by its actual creation timestamp.
+	sort.Slice(candidates, func(i, j int) bool { return candidates[i].CreatedAt.After(candidates[j].CreatedAt) })
+
+	return candidates[0], nil
+}
+
+// selfLinkImageID extracts the trailing image ID from a Yandex Cloud
+// Compute self-link, e.g. ".../images/<id>" -> "<id>".
+func selfLinkImageID(selfLink string) string {
+	parts := strings.Split(strings.TrimRight(selfLink, "/"), "/")
+	return parts[len(parts)-1]
+}
+
+func resolveImageByName(d imageLister, folderID string, name string) (*Image, error) {
+	images, err := d.ListImages(folderID, fmt.Sprintf("name=\"%s\"", name))
+	if err != nil {
+		return nil, err
+	}
+	return exactlyOneImage(images, fmt.Sprintf("name %q", name))
+}
+
+// resolveImageByLabels parses a comma-separated "key=value" label selector
+// and looks up the image(s) matching all of them.
+func resolveImageByLabels(d imageLister, folderID string, selector string) (*Image, error) {
+	var terms []string
+
+	for _, pair := range strings.Split(selector, ",") {
+		kv := strings.SplitN(pair, "=", 2)
+		if len(kv) != 2 || kv[0] == "" {
+			return nil, fmt.Errorf("invalid label selector %q: expected comma-separated key=value pairs", selector)
+		}
+		terms = append(terms, fmt.Sprintf("labels.%s=\"%s\"", kv[0], kv[1]))
+	}
+
+	images, err := d.ListImages(folderID, strings.Join(terms, " AND "))
+	if err != nil {
+		return nil, err
+	}
+	return exactlyOneImage(images, fmt.Sprintf("labels %q", selector))
+}
+
+func exactlyOneImage(images []*Image, describedAs string) (*Image, error) {
+	switch len(images) {
+	case 0:
+		return nil, fmt.Errorf("no image found matching %s", describedAs)
+	case 1:
+		return images[0], nil
+	default:
+		return nil, fmt.Errorf("%d images found matching %s, expected exactly one", len(images), describedAs)
+	}
+}
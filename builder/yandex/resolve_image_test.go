@@ -0,0 +1,178 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package yandex
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+// fakeImageLister is an in-memory imageLister for exercising resolveImage
+// and its helpers without a real Yandex Cloud SDK client.
+type fakeImageLister struct {
+	images map[string]*Image // keyed by ID, for GetImage
+	all    []*Image          // returned (unfiltered) by ListImages
+}
+
+func (f *fakeImageLister) GetImage(imageID string) (*Image, error) {
+	image, ok := f.images[imageID]
+	if !ok {
+		return nil, fmt.Errorf("no such image %q", imageID)
+	}
+	return image, nil
+}
+
+func (f *fakeImageLister) ListImages(folderID string, filter string) ([]*Image, error) {
+	var matched []*Image
+	for _, image := range f.all {
+		if imageMatchesFakeFilter(image, filter) {
+			matched = append(matched, image)
+		}
+	}
+	return matched, nil
+}
+
+// imageMatchesFakeFilter is a deliberately narrow stand-in for the Compute
+// API's list filter language, supporting only the exact filter shapes
+// resolveImage's helpers build: `family="..."`, `name="..."`, and
+// `labels.k="v"` terms joined with " AND ".
+func imageMatchesFakeFilter(image *Image, filter string) bool {
+	switch {
+	case filter == "":
+		return true
+	case hasFilterValue(filter, "family=", image.Labels["family"]):
+		return true
+	case hasFilterValue(filter, "name=", image.Name):
+		return true
+	default:
+		return matchesLabelFilter(image, filter)
+	}
+}
+
+func hasFilterValue(filter string, prefix string, value string) bool {
+	return filter == fmt.Sprintf("%s%q", prefix, value)
+}
+
+func matchesLabelFilter(image *Image, filter string) bool {
+	for k, v := range image.Labels {
+		term := fmt.Sprintf("labels.%s=%q", k, v)
+		if filter == term {
+			return true
+		}
+	}
+	return false
+}
+
+func TestResolveImageSelfLink(t *testing.T) {
+	lister := &fakeImageLister{images: map[string]*Image{
+		"img-1": {ID: "img-1", Name: "ubuntu"},
+	}}
+
+	image, err := resolveImage(lister, "folder-1", "https://example.com/images/img-1")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if image.ID != "img-1" {
+		t.Fatalf("expected img-1, got %q", image.ID)
+	}
+}
+
+func TestResolveImageByName(t *testing.T) {
+	lister := &fakeImageLister{all: []*Image{
+		{ID: "img-1", Name: "ubuntu-2204"},
+	}}
+
+	image, err := resolveImage(lister, "folder-1", "ubuntu-2204")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if image.ID != "img-1" {
+		t.Fatalf("expected img-1, got %q", image.ID)
+	}
+}
+
+func TestResolveImageByLabels(t *testing.T) {
+	lister := &fakeImageLister{all: []*Image{
+		{ID: "img-1", Name: "ubuntu", Labels: map[string]string{"os": "ubuntu"}},
+	}}
+
+	image, err := resolveImage(lister, "folder-1", "os=ubuntu")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if image.ID != "img-1" {
+		t.Fatalf("expected img-1, got %q", image.ID)
+	}
+}
+
+func TestResolveLatestInFamilyOrdersByCreationTime(t *testing.T) {
+	now := time.Date(2026, 7, 30, 0, 0, 0, 0, time.UTC)
+
+	lister := &fakeImageLister{all: []*Image{
+		// Deliberately out of ID order relative to creation time: "img-9"
+		// sorts after "img-2" lexically, but img-9 is actually older.
+		{ID: "img-2", Name: "newest", Labels: map[string]string{"family": "ubuntu"}, CreatedAt: now},
+		{ID: "img-9", Name: "oldest", Labels: map[string]string{"family": "ubuntu"}, CreatedAt: now.Add(-24 * time.Hour)},
+	}}
+
+	image, err := resolveImage(lister, "folder-1", "family/ubuntu")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if image.ID != "img-2" {
+		t.Fatalf("expected the most recently created image img-2, got %q", image.ID)
+	}
+}
+
+func TestResolveLatestInFamilySkipsDeprecated(t *testing.T) {
+	now := time.Date(2026, 7, 30, 0, 0, 0, 0, time.UTC)
+
+	lister := &fakeImageLister{all: []*Image{
+		{ID: "img-1", Name: "deprecated", Labels: map[string]string{"family": "ubuntu", "deprecated": "DEPRECATED"}, CreatedAt: now},
+		{ID: "img-2", Name: "active", Labels: map[string]string{"family": "ubuntu"}, CreatedAt: now.Add(-24 * time.Hour)},
+	}}
+
+	image, err := resolveImage(lister, "folder-1", "family/ubuntu")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if image.ID != "img-2" {
+		t.Fatalf("expected the non-deprecated image img-2, got %q", image.ID)
+	}
+}
+
+func TestResolveLatestInFamilyNoneLeft(t *testing.T) {
+	lister := &fakeImageLister{all: []*Image{
+		{ID: "img-1", Name: "deprecated", Labels: map[string]string{"family": "ubuntu", "deprecated": "DEPRECATED"}},
+	}}
+
+	if _, err := resolveImage(lister, "folder-1", "family/ubuntu"); err == nil {
+		t.Fatalf("expected error when every image in the family is deprecated")
+	}
+}
+
+func TestExactlyOneImage(t *testing.T) {
+	cases := []struct {
+		Name   string
+		Images []*Image
+		Err    bool
+	}{
+		{Name: "none found is an error", Images: nil, Err: true},
+		{Name: "exactly one is ok", Images: []*Image{{ID: "img-1"}}, Err: false},
+		{Name: "more than one is an error", Images: []*Image{{ID: "img-1"}, {ID: "img-2"}}, Err: true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			_, err := exactlyOneImage(tc.Images, "test selector")
+			if tc.Err && err == nil {
+				t.Fatalf("expected error, got none")
+			}
+			if !tc.Err && err != nil {
+				t.Fatalf("expected no error, got %s", err)
+			}
+		})
+	}
+}
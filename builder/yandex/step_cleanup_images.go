@@ -0,0 +1,74 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package yandex
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/hashicorp/packer/packer"
+	"github.com/mitchellh/multistep"
+)
+
+// StepCleanupImages enforces an image rotation policy within the image
+// family the build targets: it keeps the most recent KeepImageCount images
+// and, when DeprecateOldImages is set, marks everything older as deprecated
+// instead of deleting it outright.
+type StepCleanupImages struct {
+	KeepImageCount     int
+	DeprecateOldImages bool
+}
+
+func (s *StepCleanupImages) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
+	driver := state.Get("driver").(Driver)
+	ui := state.Get("ui").(packer.Ui)
+	config := state.Get("config").(*Config)
+
+	if s.KeepImageCount <= 0 {
+		return multistep.ActionContinue
+	}
+
+	images, err := driver.ListImages(config.TargetImageFolderID, fmt.Sprintf("family=\"%s\"", config.ImageFamily))
+	if err != nil {
+		state.Put("error", fmt.Errorf("Error listing images for cleanup: %s", err))
+		return multistep.ActionHalt
+	}
+
+	if len(images) <= s.KeepImageCount {
+		return multistep.ActionContinue
+	}
+
+	// Image IDs are opaque Yandex Cloud resource identifiers with no
+	// guaranteed lexical ordering by creation time, so the newest image
+	// must be picked by its actual creation timestamp.
+	sort.Slice(images, func(i, j int) bool { return images[i].CreatedAt.After(images[j].CreatedAt) })
+
+	newest := images[0]
+	stale := images[s.KeepImageCount:]
+
+	for _, image := range stale {
+		if s.DeprecateOldImages {
+			ui.Say(fmt.Sprintf("Deprecating old image: %s", image.Name))
+			if err := driver.DeprecateImage(image.ID, newest.ID, "DEPRECATED"); err != nil {
+				state.Put("error", fmt.Errorf("Error deprecating image '%s': %s", image.Name, err))
+				return multistep.ActionHalt
+			}
+			continue
+		}
+
+		ui.Say(fmt.Sprintf("Deleting old image: %s", image.Name))
+		if err := driver.DeleteImage(image.ID); err != nil {
+			state.Put("error", fmt.Errorf("Error deleting image '%s': %s", image.Name, err))
+			return multistep.ActionHalt
+		}
+	}
+
+	return multistep.ActionContinue
+}
+
+func (s *StepCleanupImages) Cleanup(state multistep.StateBag) {
+	// Nothing to clean up; this step only prunes images that are no longer
+	// wanted, so there's no partial state to undo on a failed build.
+}
@@ -0,0 +1,202 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package yandex
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/packer/packer"
+	"github.com/mitchellh/multistep"
+	ycsdk "github.com/yandex-cloud/go-sdk"
+)
+
+// fakeDriver is an in-memory Driver for exercising StepCleanupImages
+// without a real Yandex Cloud SDK client. Only the image-management methods
+// StepCleanupImages actually calls do anything; the rest exist to satisfy
+// the interface.
+type fakeDriver struct {
+	images []*Image
+
+	deleted      []string
+	deprecated   map[string]string // id -> replacementID
+	listErr      error
+	deleteErr    error
+	deprecateErr error
+}
+
+func (f *fakeDriver) DeleteImage(id string) error {
+	if f.deleteErr != nil {
+		return f.deleteErr
+	}
+	f.deleted = append(f.deleted, id)
+	return nil
+}
+
+func (f *fakeDriver) SDK() *ycsdk.SDK { return nil }
+
+func (f *fakeDriver) GetImage(imageID string) (*Image, error) {
+	for _, image := range f.images {
+		if image.ID == imageID {
+			return image, nil
+		}
+	}
+	return nil, fmt.Errorf("no such image %q", imageID)
+}
+
+func (f *fakeDriver) GetImageFromFolder(ctx context.Context, folderID string, family string) (*Image, error) {
+	return nil, fmt.Errorf("not implemented")
+}
+
+func (f *fakeDriver) ListImages(folderID string, filter string) ([]*Image, error) {
+	if f.listErr != nil {
+		return nil, f.listErr
+	}
+	return f.images, nil
+}
+
+func (f *fakeDriver) SetImageLabels(id string, labels map[string]string) error {
+	return nil
+}
+
+func (f *fakeDriver) DeprecateImage(id string, replacementID string, state string) error {
+	if f.deprecateErr != nil {
+		return f.deprecateErr
+	}
+	if f.deprecated == nil {
+		f.deprecated = make(map[string]string)
+	}
+	f.deprecated[id] = replacementID
+	return nil
+}
+
+func (f *fakeDriver) ResolveImage(ctx context.Context, folderID string, spec string) (*Image, error) {
+	return nil, fmt.Errorf("not implemented")
+}
+
+func (f *fakeDriver) CreateInstance(ctx context.Context, spec InstanceSpec) (string, error) {
+	return "", fmt.Errorf("not implemented")
+}
+
+func (f *fakeDriver) GetInstanceAddress(ctx context.Context, instanceID string) (string, error) {
+	return "", fmt.Errorf("not implemented")
+}
+
+func (f *fakeDriver) GetInstanceBootDiskID(ctx context.Context, instanceID string) (string, error) {
+	return "", fmt.Errorf("not implemented")
+}
+
+func (f *fakeDriver) DeleteInstance(ctx context.Context, instanceID string) error {
+	return fmt.Errorf("not implemented")
+}
+
+func (f *fakeDriver) CreateImageFromDisk(ctx context.Context, folderID string, imageName string, family string, diskID string, productIds []string) (*Image, error) {
+	return nil, fmt.Errorf("not implemented")
+}
+
+// fakeUi is a no-op packer.Ui for tests that need one on the state bag but
+// don't care about its output.
+type fakeUi struct{}
+
+func (f *fakeUi) Ask(string) (string, error) { return "", nil }
+func (f *fakeUi) Say(string)                 {}
+func (f *fakeUi) Message(string)             {}
+func (f *fakeUi) Error(string)               {}
+func (f *fakeUi) Machine(string, ...string)  {}
+
+func testCleanupState(driver Driver, config *Config) multistep.StateBag {
+	state := new(multistep.BasicStateBag)
+	state.Put("driver", driver)
+	state.Put("ui", packer.Ui(&fakeUi{}))
+	state.Put("config", config)
+	return state
+}
+
+func TestStepCleanupImagesUnderLimitKeepsEverything(t *testing.T) {
+	now := time.Now()
+	driver := &fakeDriver{images: []*Image{
+		{ID: "img-1", Name: "one", CreatedAt: now},
+		{ID: "img-2", Name: "two", CreatedAt: now.Add(-time.Hour)},
+	}}
+	config := &Config{ImageFamily: "ubuntu"}
+	state := testCleanupState(driver, config)
+
+	step := &StepCleanupImages{KeepImageCount: 2}
+	if action := step.Run(context.Background(), state); action != multistep.ActionContinue {
+		t.Fatalf("expected ActionContinue, got %v", action)
+	}
+	if len(driver.deleted) != 0 {
+		t.Fatalf("expected nothing deleted, got %v", driver.deleted)
+	}
+}
+
+func TestStepCleanupImagesDeletesStaleImages(t *testing.T) {
+	now := time.Now()
+	driver := &fakeDriver{images: []*Image{
+		{ID: "img-newest", Name: "newest", CreatedAt: now},
+		{ID: "img-middle", Name: "middle", CreatedAt: now.Add(-time.Hour)},
+		// Deliberately out of ID order relative to creation time.
+		{ID: "img-z-oldest", Name: "oldest", CreatedAt: now.Add(-2 * time.Hour)},
+	}}
+	config := &Config{ImageFamily: "ubuntu"}
+	state := testCleanupState(driver, config)
+
+	step := &StepCleanupImages{KeepImageCount: 1}
+	if action := step.Run(context.Background(), state); action != multistep.ActionContinue {
+		t.Fatalf("expected ActionContinue, got %v", action)
+	}
+
+	if len(driver.deleted) != 2 {
+		t.Fatalf("expected 2 images deleted, got %v", driver.deleted)
+	}
+	for _, id := range []string{"img-middle", "img-z-oldest"} {
+		found := false
+		for _, deleted := range driver.deleted {
+			if deleted == id {
+				found = true
+			}
+		}
+		if !found {
+			t.Fatalf("expected %q to be deleted, got %v", id, driver.deleted)
+		}
+	}
+}
+
+func TestStepCleanupImagesDeprecatesInsteadOfDeleting(t *testing.T) {
+	now := time.Now()
+	driver := &fakeDriver{images: []*Image{
+		{ID: "img-newest", Name: "newest", CreatedAt: now},
+		{ID: "img-oldest", Name: "oldest", CreatedAt: now.Add(-time.Hour)},
+	}}
+	config := &Config{ImageFamily: "ubuntu"}
+	state := testCleanupState(driver, config)
+
+	step := &StepCleanupImages{KeepImageCount: 1, DeprecateOldImages: true}
+	if action := step.Run(context.Background(), state); action != multistep.ActionContinue {
+		t.Fatalf("expected ActionContinue, got %v", action)
+	}
+
+	if len(driver.deleted) != 0 {
+		t.Fatalf("expected nothing deleted, got %v", driver.deleted)
+	}
+	if replacement, ok := driver.deprecated["img-oldest"]; !ok || replacement != "img-newest" {
+		t.Fatalf("expected img-oldest to be deprecated with replacement img-newest, got %v", driver.deprecated)
+	}
+}
+
+func TestStepCleanupImagesDisabledWhenKeepCountIsZero(t *testing.T) {
+	driver := &fakeDriver{images: []*Image{{ID: "img-1"}}}
+	config := &Config{ImageFamily: "ubuntu"}
+	state := testCleanupState(driver, config)
+
+	step := &StepCleanupImages{}
+	if action := step.Run(context.Background(), state); action != multistep.ActionContinue {
+		t.Fatalf("expected ActionContinue, got %v", action)
+	}
+	if len(driver.deleted) != 0 {
+		t.Fatalf("expected nothing deleted, got %v", driver.deleted)
+	}
+}
@@ -0,0 +1,47 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package yandex
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/packer/packer"
+	"github.com/mitchellh/multistep"
+)
+
+// StepCreateImage captures the build instance's boot disk into a new image
+// once provisioning has finished, and sets it as the build's artifact.
+type StepCreateImage struct{}
+
+func (s *StepCreateImage) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
+	config := state.Get("config").(*Config)
+	driver := state.Get("driver").(Driver)
+	ui := state.Get("ui").(packer.Ui)
+	instanceID := state.Get("instance_id").(string)
+
+	ui.Say("Getting the build instance's boot disk...")
+	diskID, err := driver.GetInstanceBootDiskID(ctx, instanceID)
+	if err != nil {
+		state.Put("error", fmt.Errorf("error getting boot disk: %s", err))
+		return multistep.ActionHalt
+	}
+
+	ui.Say(fmt.Sprintf("Creating image %q...", config.ImageName))
+	image, err := driver.CreateImageFromDisk(
+		ctx, config.TargetImageFolderID, config.ImageName, config.ImageFamily, diskID, config.ImageProductIds)
+	if err != nil {
+		state.Put("error", fmt.Errorf("error creating image: %s", err))
+		return multistep.ActionHalt
+	}
+
+	state.Put("artifact", &Artifact{image: image})
+
+	return multistep.ActionContinue
+}
+
+func (s *StepCreateImage) Cleanup(state multistep.StateBag) {
+	// Nothing to clean up: once the image has been created, there is no
+	// partial state to undo.
+}
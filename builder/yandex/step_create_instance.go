@@ -0,0 +1,160 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package yandex
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/hashicorp/packer/packer"
+	"github.com/mitchellh/multistep"
+)
+
+const bytesPerGigabyte = 1 << 30
+
+// StepCreateInstance creates the build instance: it resolves the source
+// image, assembles instance metadata (including anything
+// StepPrepareCloudInit put on the state bag), and waits for the instance to
+// come up with an address the communicator can connect to.
+type StepCreateInstance struct{}
+
+func (s *StepCreateInstance) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
+	config := state.Get("config").(*Config)
+	driver := state.Get("driver").(Driver)
+	ui := state.Get("ui").(packer.Ui)
+
+	if _, ok := state.GetOk("cloud_init_seed_iso"); ok {
+		state.Put("error", fmt.Errorf(
+			"cloud_init.seed_iso is not supported yet: attaching a NoCloud seed ISO as a "+
+				"secondary disk requires uploading it as a disk image first, which this driver "+
+				"does not implement; use cloud_init.user_data without seed_iso instead"))
+		return multistep.ActionHalt
+	}
+
+	ui.Say("Resolving source image...")
+	sourceImageID, err := resolveSourceImageID(ctx, driver, config.FolderID, config)
+	if err != nil {
+		state.Put("error", fmt.Errorf("error resolving source image: %s", err))
+		return multistep.ActionHalt
+	}
+
+	metadata, err := instanceMetadata(config, state)
+	if err != nil {
+		state.Put("error", err)
+		return multistep.ActionHalt
+	}
+
+	name := fmt.Sprintf("packer-builder-%d", time.Now().Unix())
+
+	ui.Say(fmt.Sprintf("Creating instance %q...", name))
+	instanceID, err := driver.CreateInstance(ctx, InstanceSpec{
+		FolderID:         config.FolderID,
+		Name:             name,
+		ZoneID:           config.Zone,
+		PlatformID:       config.PlatformID,
+		SubnetID:         config.SubnetID,
+		ServiceAccountID: config.ServiceAccountID,
+		Cores:            config.InstanceCores,
+		MemoryBytes:      config.InstanceMemGb * bytesPerGigabyte,
+		DiskSizeBytes:    int64(config.DiskSizeGb) * bytesPerGigabyte,
+		ImageID:          sourceImageID,
+		Metadata:         metadata,
+	})
+	if err != nil {
+		state.Put("error", fmt.Errorf("error creating instance: %s", err))
+		return multistep.ActionHalt
+	}
+	state.Put("instance_id", instanceID)
+
+	ui.Say("Waiting for instance IP address...")
+	address, err := driver.GetInstanceAddress(ctx, instanceID)
+	if err != nil {
+		state.Put("error", fmt.Errorf("error getting instance address: %s", err))
+		return multistep.ActionHalt
+	}
+	state.Put("instance_ip", address)
+
+	return multistep.ActionContinue
+}
+
+func (s *StepCreateInstance) Cleanup(state multistep.StateBag) {
+	instanceIDRaw, ok := state.GetOk("instance_id")
+	if !ok {
+		return
+	}
+
+	ui := state.Get("ui").(packer.Ui)
+	driver := state.Get("driver").(Driver)
+
+	ui.Say("Deleting build instance...")
+	if err := driver.DeleteInstance(context.Background(), instanceIDRaw.(string)); err != nil {
+		ui.Error(fmt.Sprintf("Error deleting instance: %s", err))
+	}
+}
+
+// resolveSourceImageID turns config's source-image fields (see
+// ImageSourceSpec) into a concrete image ID. Config.Prepare has already
+// validated that they select an image in exactly one way; when both
+// source_image_id and source_image_family are set, source_image_id wins.
+func resolveSourceImageID(ctx context.Context, driver Driver, folderID string, config *Config) (string, error) {
+	switch {
+	case config.SourceImageID != "":
+		return config.SourceImageID, nil
+
+	case config.SourceImageFamily != "":
+		image, err := driver.ResolveImage(ctx, folderID, "family/"+config.SourceImageFamily)
+		if err != nil {
+			return "", err
+		}
+		return image.ID, nil
+
+	case config.SourceImage != "":
+		image, err := driver.ResolveImage(ctx, folderID, config.SourceImage)
+		if err != nil {
+			return "", err
+		}
+		return image.ID, nil
+
+	case len(config.SourceImageLabels) > 0:
+		spec := ImageSourceSpec{SourceImageLabels: config.SourceImageLabels}
+		image, err := driver.ResolveImage(ctx, folderID, spec.Spec())
+		if err != nil {
+			return "", err
+		}
+		return image.ID, nil
+
+	default:
+		return "", fmt.Errorf("no source image specified")
+	}
+}
+
+// instanceMetadata merges config.Metadata, the contents of
+// config.MetadataFromFile, and whatever StepPrepareCloudInit put under
+// "cloud_init_metadata" into the metadata map the build instance is
+// created with.
+func instanceMetadata(config *Config, state multistep.StateBag) (map[string]string, error) {
+	metadata := make(map[string]string)
+
+	for k, v := range config.Metadata {
+		metadata[k] = v
+	}
+
+	for key, path := range config.MetadataFromFile {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("error reading metadata_from_file %q for key %q: %s", path, key, err)
+		}
+		metadata[key] = string(data)
+	}
+
+	if raw, ok := state.GetOk("cloud_init_metadata"); ok {
+		for k, v := range raw.(map[string]string) {
+			metadata[k] = v
+		}
+	}
+
+	return metadata, nil
+}
@@ -0,0 +1,46 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package yandex
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/packer/packer"
+	"github.com/mitchellh/multistep"
+)
+
+// StepPrepareCloudInit renders config.CloudInit into whatever the instance
+// needs at create time: either a "user-data" metadata entry, or a NoCloud
+// seed ISO. It runs before the instance is created so that step can read
+// "cloud_init_metadata" / "cloud_init_seed_iso" off the state bag: the
+// metadata entry is merged into the instance metadata, while a seed ISO
+// makes StepCreateInstance halt with an error instead of attaching it.
+type StepPrepareCloudInit struct{}
+
+func (s *StepPrepareCloudInit) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
+	config := state.Get("config").(*Config)
+	ui := state.Get("ui").(packer.Ui)
+
+	if entries := config.CloudInit.MetadataEntries(); entries != nil {
+		state.Put("cloud_init_metadata", entries)
+	}
+
+	seedISO, err := config.CloudInit.SeedISOBytes()
+	if err != nil {
+		state.Put("error", fmt.Errorf("error building cloud-init seed ISO: %s", err))
+		return multistep.ActionHalt
+	}
+	if seedISO != nil {
+		ui.Say("Building cloud-init NoCloud seed ISO")
+		state.Put("cloud_init_seed_iso", seedISO)
+	}
+
+	return multistep.ActionContinue
+}
+
+func (s *StepPrepareCloudInit) Cleanup(state multistep.StateBag) {
+	// Nothing to clean up; the rendered metadata/ISO bytes only live in the
+	// state bag for the instance-creation step to consume.
+}
@@ -0,0 +1,39 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package yandexoci
+
+import "fmt"
+
+// BuilderId distinguishes this post-processor's artifacts from those of the
+// yandex builder or other post-processors in a chain.
+const BuilderId = "packer.post-processor.yandex-oci"
+
+// Artifact represents an image pushed to an OCI-compatible registry.
+type Artifact struct {
+	ref string
+}
+
+func (*Artifact) BuilderId() string {
+	return BuilderId
+}
+
+func (a *Artifact) Id() string {
+	return a.ref
+}
+
+func (a *Artifact) Files() []string {
+	return nil
+}
+
+func (a *Artifact) String() string {
+	return fmt.Sprintf("Pushed OCI image: %s", a.ref)
+}
+
+func (*Artifact) State(name string) interface{} {
+	return nil
+}
+
+func (*Artifact) Destroy() error {
+	return nil
+}
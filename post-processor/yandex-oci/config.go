@@ -0,0 +1,82 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package yandexoci
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/packer/common"
+	"github.com/hashicorp/packer/helper/config"
+	"github.com/hashicorp/packer/packer"
+	"github.com/hashicorp/packer/template/interpolate"
+)
+
+// MediaType selects the OCI/Docker manifest flavor written to the registry.
+type MediaType string
+
+const (
+	MediaTypeOCIManifest    MediaType = "application/vnd.oci.image.manifest.v1+json"
+	MediaTypeDockerManifest MediaType = "application/vnd.docker.distribution.manifest.v2+json"
+)
+
+type Config struct {
+	common.PackerConfig `mapstructure:",squash"`
+
+	// Registry is the OCI registry host, e.g. cr.yandex.
+	Registry string `mapstructure:"registry" required:"true"`
+	// Repository is the registry repository to push to, e.g.
+	// crp1234567890/my-image.
+	Repository string `mapstructure:"repository" required:"true"`
+	// Tag is the tag applied to the pushed artifact. Defaults to "latest".
+	Tag string `mapstructure:"tag" required:"false"`
+	// AuthKeyFile is a path to a Yandex Cloud service account key JSON file,
+	// used to mint an IAM token for registry authentication.
+	AuthKeyFile string `mapstructure:"auth_key_file" required:"true"`
+	// MediaType controls whether the pushed manifest is an OCI image
+	// manifest or a Docker v2 manifest. Defaults to the OCI manifest.
+	MediaType string `mapstructure:"media_type" required:"false"`
+
+	ctx interpolate.Context
+}
+
+func (c *Config) Prepare(raws ...interface{}) error {
+	err := config.Decode(c, &config.DecodeOpts{
+		Interpolate:        true,
+		InterpolateContext: &c.ctx,
+	}, raws...)
+	if err != nil {
+		return err
+	}
+
+	if c.Tag == "" {
+		c.Tag = "latest"
+	}
+	if c.MediaType == "" {
+		c.MediaType = string(MediaTypeOCIManifest)
+	}
+
+	var errs *packer.MultiError
+
+	if c.Registry == "" {
+		errs = packer.MultiErrorAppend(errs, fmt.Errorf("registry must be specified"))
+	}
+	if c.Repository == "" {
+		errs = packer.MultiErrorAppend(errs, fmt.Errorf("repository must be specified"))
+	}
+	if c.AuthKeyFile == "" {
+		errs = packer.MultiErrorAppend(errs, fmt.Errorf("auth_key_file must be specified"))
+	}
+	switch MediaType(c.MediaType) {
+	case MediaTypeOCIManifest, MediaTypeDockerManifest:
+	default:
+		errs = packer.MultiErrorAppend(errs, fmt.Errorf("media_type must be one of %q or %q",
+			MediaTypeOCIManifest, MediaTypeDockerManifest))
+	}
+
+	if errs != nil && len(errs.Errors) > 0 {
+		return errs
+	}
+
+	return nil
+}
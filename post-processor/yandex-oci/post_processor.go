@@ -0,0 +1,191 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+// Package yandexoci implements a post-processor that repackages the disk
+// image produced by a Yandex Compute build as an OCI (or Docker v2) image
+// and pushes it to Yandex Container Registry or any other OCI-compatible
+// registry.
+package yandexoci
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/tarball"
+	"github.com/google/go-containerregistry/pkg/v1/types"
+
+	"github.com/hashicorp/packer/packer"
+	"github.com/yandex-cloud/go-genproto/yandex/cloud/compute/v1"
+	ycsdk "github.com/yandex-cloud/go-sdk"
+	"github.com/yandex-cloud/go-sdk/iamkey"
+)
+
+type PostProcessor struct {
+	config Config
+}
+
+func (p *PostProcessor) Configure(raws ...interface{}) error {
+	return p.config.Prepare(raws...)
+}
+
+// PostProcess expects artifact.Files() to contain one local disk image
+// (qcow2 or raw) per architecture, produced by an earlier export step. A
+// single file is wrapped as a single-layer OCI image; more than one file
+// is pushed as a multi-arch OCI image index, one manifest per file.
+func (p *PostProcessor) PostProcess(ui packer.Ui, artifact packer.Artifact) (packer.Artifact, bool, bool, error) {
+	files := artifact.Files()
+	if len(files) == 0 {
+		return nil, false, false, fmt.Errorf("yandex-oci post-processor requires at least one disk image file in the artifact")
+	}
+
+	ref, err := name.NewTag(fmt.Sprintf("%s/%s:%s", p.config.Registry, p.config.Repository, p.config.Tag))
+	if err != nil {
+		return nil, false, false, fmt.Errorf("error parsing destination reference: %s", err)
+	}
+
+	auth, err := p.authenticator()
+	if err != nil {
+		return nil, false, false, err
+	}
+
+	ctx := context.Background()
+	annotations, err := p.annotations(ctx, artifact)
+	if err != nil {
+		return nil, false, false, err
+	}
+
+	if len(files) == 1 {
+		img, err := p.imageFromDisk(files[0], annotations)
+		if err != nil {
+			return nil, false, false, err
+		}
+
+		ui.Say(fmt.Sprintf("Packaging %s as an OCI image for %s", files[0], ref))
+		if err := remote.Write(ref, img, remote.WithAuth(auth)); err != nil {
+			return nil, false, false, fmt.Errorf("error pushing image to %s: %s", ref, err)
+		}
+
+		ui.Say(fmt.Sprintf("Pushed %s", ref))
+		return &Artifact{ref: ref.String()}, false, false, nil
+	}
+
+	ui.Say(fmt.Sprintf("Packaging %d disk images as a multi-arch OCI index for %s", len(files), ref))
+
+	idx := empty.Index
+	for _, file := range files {
+		img, err := p.imageFromDisk(file, annotations)
+		if err != nil {
+			return nil, false, false, err
+		}
+
+		idx = mutate.AppendManifests(idx, mutate.IndexAddendum{Add: img})
+	}
+
+	if err := remote.WriteIndex(ref, idx, remote.WithAuth(auth)); err != nil {
+		return nil, false, false, fmt.Errorf("error pushing image index to %s: %s", ref, err)
+	}
+
+	ui.Say(fmt.Sprintf("Pushed %s", ref))
+
+	return &Artifact{ref: ref.String()}, false, false, nil
+}
+
+// imageFromDisk wraps a single exported disk image as a one-layer OCI
+// image carrying the given annotations, with its manifest media type set
+// from p.config.MediaType.
+func (p *PostProcessor) imageFromDisk(diskFile string, annotations map[string]string) (v1.Image, error) {
+	layer, err := tarball.LayerFromFile(diskFile)
+	if err != nil {
+		return nil, fmt.Errorf("error building OCI layer from %s: %s", diskFile, err)
+	}
+
+	img, err := mutate.Append(empty.Image, mutate.Addendum{Layer: layer})
+	if err != nil {
+		return nil, fmt.Errorf("error assembling OCI image from %s: %s", diskFile, err)
+	}
+
+	img = mutate.MediaType(img, manifestMediaType(p.config.MediaType))
+
+	return mutate.Annotations(img, annotations).(v1.Image), nil
+}
+
+// manifestMediaType translates the configured media_type into the
+// go-containerregistry types.MediaType used for the pushed manifest.
+func manifestMediaType(configured string) types.MediaType {
+	if MediaType(configured) == MediaTypeDockerManifest {
+		return types.DockerManifestSchema2
+	}
+	return types.OCIManifestSchema1
+}
+
+// annotations fetches the source image's Labels/ProductIds from Yandex
+// Compute and turns them into OCI annotations, in addition to the
+// standard ref.name annotation.
+func (p *PostProcessor) annotations(ctx context.Context, artifact packer.Artifact) (map[string]string, error) {
+	annotations := map[string]string{
+		"org.opencontainers.image.ref.name": artifact.Id(),
+	}
+
+	image, err := p.fetchSourceImage(ctx, artifact.Id())
+	if err != nil {
+		return nil, fmt.Errorf("error reading source image metadata for annotations: %s", err)
+	}
+
+	for k, v := range image.Labels {
+		annotations["cloud.yandex.image.label."+k] = v
+	}
+	if len(image.ProductIds) > 0 {
+		annotations["cloud.yandex.image.product-ids"] = strings.Join(image.ProductIds, ",")
+	}
+
+	return annotations, nil
+}
+
+// fetchSourceImage reads the Compute image metadata for imageID using the
+// same service account credentials as the registry push.
+func (p *PostProcessor) fetchSourceImage(ctx context.Context, imageID string) (*compute.Image, error) {
+	key, err := iamkey.ReadFromJSONFile(p.config.AuthKeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("error reading auth_key_file: %s", err)
+	}
+
+	credentials, err := ycsdk.ServiceAccountKey(key)
+	if err != nil {
+		return nil, fmt.Errorf("error building credentials from auth_key_file: %s", err)
+	}
+
+	sdk, err := ycsdk.Build(ctx, ycsdk.Config{Credentials: credentials})
+	if err != nil {
+		return nil, fmt.Errorf("error building Yandex Cloud client: %s", err)
+	}
+
+	return sdk.Compute().Image().Get(ctx, &compute.GetImageRequest{ImageId: imageID})
+}
+
+// authenticator exchanges the configured service account key for an IAM
+// token and presents it to the registry as a bearer credential.
+func (p *PostProcessor) authenticator() (authn.Authenticator, error) {
+	key, err := iamkey.ReadFromJSONFile(p.config.AuthKeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("error reading auth_key_file: %s", err)
+	}
+
+	credentials, err := ycsdk.ServiceAccountKey(key)
+	if err != nil {
+		return nil, fmt.Errorf("error building credentials from auth_key_file: %s", err)
+	}
+
+	token, err := credentials.IAMToken(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("error minting IAM token: %s", err)
+	}
+
+	return &authn.Bearer{Token: token.IamToken}, nil
+}